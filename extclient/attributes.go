@@ -0,0 +1,14 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import "slices"
+
+// AppendDistinct appends the value unless it is already contained, e.g. to collect distinct target attribute values.
+func AppendDistinct(values []string, value string) []string {
+	if slices.Contains(values, value) {
+		return values
+	}
+	return append(values, value)
+}
@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *IstioClient) CreateAuthorizationPolicy(ctx context.Context, authorizationPolicy *securityv1.AuthorizationPolicy) error {
+	_, err := c.clientset.SecurityV1().AuthorizationPolicies(authorizationPolicy.Namespace).Create(ctx, authorizationPolicy, v1.CreateOptions{})
+	return err
+}
+
+// DeleteAuthorizationPolicy deletes the AuthorizationPolicy. An already deleted AuthorizationPolicy is not considered
+// an error.
+func (c *IstioClient) DeleteAuthorizationPolicy(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.SecurityV1().AuthorizationPolicies(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
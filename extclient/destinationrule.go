@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateDestinationRule fetches the current DestinationRule, applies the given modification and writes it back.
+func (c *IstioClient) UpdateDestinationRule(ctx context.Context, namespace string, name string, modify func(dr *networkingv1.DestinationRule) error) error {
+	dr, err := c.clientset.NetworkingV1().DestinationRules(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	dr = dr.DeepCopy()
+	if err = modify(dr); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.NetworkingV1().DestinationRules(namespace).Update(ctx, dr, v1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,33 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	networkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	// ManagedByLabel marks resources created by the extension, so that leftovers can be found and cleaned up.
+	ManagedByLabel = "app.kubernetes.io/managed-by"
+	ManagedByValue = "steadybit-extension-istio"
+	// ExecutionIdLabel carries the ID of the action execution which created the resource.
+	ExecutionIdLabel = "steadybit.com/execution-id"
+)
+
+func (c *IstioClient) CreateEnvoyFilter(ctx context.Context, envoyFilter *networkingv1alpha3.EnvoyFilter) error {
+	_, err := c.clientset.NetworkingV1alpha3().EnvoyFilters(envoyFilter.Namespace).Create(ctx, envoyFilter, v1.CreateOptions{})
+	return err
+}
+
+// DeleteEnvoyFilter deletes the EnvoyFilter. An already deleted EnvoyFilter is not considered an error.
+func (c *IstioClient) DeleteEnvoyFilter(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.NetworkingV1alpha3().EnvoyFilters(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
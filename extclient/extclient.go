@@ -9,19 +9,23 @@ import (
 	"flag"
 	"fmt"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/exp/slices"
 	apinetv1 "istio.io/api/networking/v1"
 	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
 	versionedClient "istio.io/client-go/pkg/clientset/versioned"
 	informers "istio.io/client-go/pkg/informers/externalversions"
 	v1lister "istio.io/client-go/pkg/listers/networking/v1"
+	corev1 "k8s.io/api/core/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/labels"
+	k8sinformers "k8s.io/client-go/informers"
+	"k8s.io/client-go/kubernetes"
+	corev1lister "k8s.io/client-go/listers/core/v1"
 	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/cache"
 	"k8s.io/client-go/tools/clientcmd"
 	"k8s.io/client-go/util/homedir"
 	"path/filepath"
+	"slices"
 	"strings"
 	"time"
 )
@@ -29,11 +33,17 @@ import (
 var Istio *IstioClient
 
 type IstioClient struct {
-	clientset               versionedClient.Interface
-	virtualServicesLister   v1lister.VirtualServiceLister
-	virtualServicesInformer cache.SharedIndexInformer
-	gatewaysLister          v1lister.GatewayLister
-	gatewaysInformer        cache.SharedIndexInformer
+	clientset                versionedClient.Interface
+	virtualServicesLister    v1lister.VirtualServiceLister
+	virtualServicesInformer  cache.SharedIndexInformer
+	gatewaysLister           v1lister.GatewayLister
+	gatewaysInformer         cache.SharedIndexInformer
+	destinationRulesLister   v1lister.DestinationRuleLister
+	destinationRulesInformer cache.SharedIndexInformer
+	serviceEntriesLister     v1lister.ServiceEntryLister
+	serviceEntriesInformer   cache.SharedIndexInformer
+	servicesLister           corev1lister.ServiceLister
+	servicesInformer         cache.SharedIndexInformer
 }
 
 func (c *IstioClient) GetVirtualServices() []*networkingv1.VirtualService {
@@ -47,17 +57,58 @@ func (c *IstioClient) GetVirtualServices() []*networkingv1.VirtualService {
 	return vs
 }
 
+func (c *IstioClient) GetDestinationRules() []*networkingv1.DestinationRule {
+	dr, err := c.destinationRulesLister.List(labels.Everything())
+
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed fetching DestinationRule resources")
+		return []*networkingv1.DestinationRule{}
+	}
+
+	return dr
+}
+
+func (c *IstioClient) GetServiceEntries() []*networkingv1.ServiceEntry {
+	se, err := c.serviceEntriesLister.List(labels.Everything())
+
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed fetching ServiceEntry resources")
+		return []*networkingv1.ServiceEntry{}
+	}
+
+	return se
+}
+
+func (c *IstioClient) GetServices() []*corev1.Service {
+	services, err := c.servicesLister.List(labels.Everything())
+
+	if err != nil {
+		log.Error().Err(err).Msgf("Failed fetching Service resources")
+		return []*corev1.Service{}
+	}
+
+	return services
+}
+
 func (c *IstioClient) GetGateways() []*networkingv1.Gateway {
 	gw, err := c.gatewaysLister.List(labels.Everything())
 
 	if err != nil {
-		log.Error().Err(err).Msgf("Failed fetching VirtualService resources")
+		log.Error().Err(err).Msgf("Failed fetching Gateway resources")
 		return []*networkingv1.Gateway{}
 	}
 
 	return gw
 }
 
+func (c *IstioClient) GetVirtualService(ctx context.Context, namespace string, name string) (*networkingv1.VirtualService, error) {
+	return c.clientset.NetworkingV1().VirtualServices(namespace).Get(ctx, name, v1.GetOptions{})
+}
+
+func (c *IstioClient) GetServiceEntry(ctx context.Context, namespace string, name string) (*networkingv1.ServiceEntry, error) {
+	return c.clientset.NetworkingV1().ServiceEntries(namespace).Get(ctx, name, v1.GetOptions{})
+}
+
 func (c *IstioClient) AddHTTPFault(ctx context.Context,
 	namespace string,
 	name string,
@@ -157,35 +208,57 @@ func (c *IstioClient) RemoveAllFaults(ctx context.Context, namespace string, nam
 	return err
 }
 
-func NewIstioClient(clientset versionedClient.Interface, stopCh <-chan struct{}) *IstioClient {
+func NewIstioClient(clientset versionedClient.Interface, kubernetesClientset kubernetes.Interface, stopCh <-chan struct{}) *IstioClient {
 	factory := informers.NewSharedInformerFactory(clientset, 0)
+	kubernetesFactory := k8sinformers.NewSharedInformerFactory(kubernetesClientset, 0)
 
 	virtualServices := factory.Networking().V1().VirtualServices()
 	virtualServicesInformer := virtualServices.Informer()
+	destinationRules := factory.Networking().V1().DestinationRules()
+	destinationRulesInformer := destinationRules.Informer()
+	gateways := factory.Networking().V1().Gateways()
+	gatewaysInformer := gateways.Informer()
+	serviceEntries := factory.Networking().V1().ServiceEntries()
+	serviceEntriesInformer := serviceEntries.Informer()
+	services := kubernetesFactory.Core().V1().Services()
+	servicesInformer := services.Informer()
 
 	go factory.Start(stopCh)
+	go kubernetesFactory.Start(stopCh)
 
 	log.Info().Msgf("Start Kubernetes cache sync.")
 	if !cache.WaitForCacheSync(stopCh,
 		virtualServicesInformer.HasSynced,
+		destinationRulesInformer.HasSynced,
+		gatewaysInformer.HasSynced,
+		serviceEntriesInformer.HasSynced,
+		servicesInformer.HasSynced,
 	) {
 		log.Fatal().Msg("Timed out waiting for caches to sync")
 	}
 	log.Info().Msgf("Caches synced.")
 
 	return &IstioClient{
-		clientset:               clientset,
-		virtualServicesLister:   virtualServices.Lister(),
-		virtualServicesInformer: virtualServicesInformer,
+		clientset:                clientset,
+		virtualServicesLister:    virtualServices.Lister(),
+		virtualServicesInformer:  virtualServicesInformer,
+		gatewaysLister:           gateways.Lister(),
+		gatewaysInformer:         gatewaysInformer,
+		destinationRulesLister:   destinationRules.Lister(),
+		destinationRulesInformer: destinationRulesInformer,
+		serviceEntriesLister:     serviceEntries.Lister(),
+		serviceEntriesInformer:   serviceEntriesInformer,
+		servicesLister:           services.Lister(),
+		servicesInformer:         servicesInformer,
 	}
 }
 
 func PrepareClient(stopCh <-chan struct{}) {
-	clientset := createIstioClientset()
-	Istio = NewIstioClient(clientset, stopCh)
+	config := createKubernetesConfig()
+	Istio = NewIstioClient(createIstioClientset(config), createKubernetesClientset(config), stopCh)
 }
 
-func createIstioClientset() versionedClient.Interface {
+func createKubernetesConfig() *rest.Config {
 	config, err := rest.InClusterConfig()
 	if err == nil {
 		log.Info().Msgf("Extension is running inside a cluster, config found")
@@ -209,6 +282,18 @@ func createIstioClientset() versionedClient.Interface {
 	config.UserAgent = "steadybit-extension-kubernetes"
 	config.Timeout = time.Second * 10
 
+	return config
+}
+
+func createKubernetesClientset(config *rest.Config) kubernetes.Interface {
+	kubernetesClient, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to create kubernetes client")
+	}
+	return kubernetesClient
+}
+
+func createIstioClientset(config *rest.Config) versionedClient.Interface {
 	istioClient, err := versionedClient.NewForConfig(config)
 	if err != nil {
 		log.Fatal().Err(err).Msg("Failed to create istio client")
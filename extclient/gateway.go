@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateGateway fetches the current Gateway, applies the given modification and writes it back.
+func (c *IstioClient) UpdateGateway(ctx context.Context, namespace string, name string, modify func(gw *networkingv1.Gateway) error) error {
+	gw, err := c.clientset.NetworkingV1().Gateways(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	gw = gw.DeepCopy()
+	if err = modify(gw); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.NetworkingV1().Gateways(namespace).Update(ctx, gw, v1.UpdateOptions{})
+	return err
+}
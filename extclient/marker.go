@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"fmt"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// ModifiedByAnnotation marks an existing resource modified by an attack with the ID of the action execution. Attacks
+// restore the settings they snapshotted before the change on stop, so a second attack on the same resource must not
+// start meanwhile. It would snapshot the first attack's change and restore it when stopping last.
+const ModifiedByAnnotation = "steadybit.com/modified-by-execution-id"
+
+// MarkModified marks the resource as modified by the execution. It fails if another execution modifies the resource
+// already.
+func MarkModified(object v1.Object, executionId string) error {
+	if modifiedBy, ok := object.GetAnnotations()[ModifiedByAnnotation]; ok && modifiedBy != executionId {
+		return fmt.Errorf("already modified by another attack (execution %s)", modifiedBy)
+	}
+
+	annotations := object.GetAnnotations()
+	if annotations == nil {
+		annotations = make(map[string]string)
+	}
+	annotations[ModifiedByAnnotation] = executionId
+	object.SetAnnotations(annotations)
+	return nil
+}
+
+// UnmarkModified removes the mark of the execution from the resource.
+func UnmarkModified(object v1.Object, executionId string) {
+	annotations := object.GetAnnotations()
+	if annotations[ModifiedByAnnotation] != executionId {
+		return
+	}
+	delete(annotations, ModifiedByAnnotation)
+	if len(annotations) == 0 {
+		annotations = nil
+	}
+	object.SetAnnotations(annotations)
+}
@@ -0,0 +1,50 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *IstioClient) ListPeerAuthentications(ctx context.Context, namespace string) ([]*securityv1.PeerAuthentication, error) {
+	list, err := c.clientset.SecurityV1().PeerAuthentications(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *IstioClient) CreatePeerAuthentication(ctx context.Context, peerAuthentication *securityv1.PeerAuthentication) error {
+	_, err := c.clientset.SecurityV1().PeerAuthentications(peerAuthentication.Namespace).Create(ctx, peerAuthentication, v1.CreateOptions{})
+	return err
+}
+
+// UpdatePeerAuthentication fetches the current PeerAuthentication, applies the given modification and writes it back.
+func (c *IstioClient) UpdatePeerAuthentication(ctx context.Context, namespace string, name string, modify func(pa *securityv1.PeerAuthentication) error) error {
+	pa, err := c.clientset.SecurityV1().PeerAuthentications(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	pa = pa.DeepCopy()
+	if err = modify(pa); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.SecurityV1().PeerAuthentications(namespace).Update(ctx, pa, v1.UpdateOptions{})
+	return err
+}
+
+// DeletePeerAuthentication deletes the PeerAuthentication. An already deleted PeerAuthentication is not considered an
+// error.
+func (c *IstioClient) DeletePeerAuthentication(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.SecurityV1().PeerAuthentications(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
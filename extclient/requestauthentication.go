@@ -0,0 +1,51 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func (c *IstioClient) ListRequestAuthentications(ctx context.Context, namespace string) ([]*securityv1.RequestAuthentication, error) {
+	list, err := c.clientset.SecurityV1().RequestAuthentications(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *IstioClient) CreateRequestAuthentication(ctx context.Context, requestAuthentication *securityv1.RequestAuthentication) error {
+	_, err := c.clientset.SecurityV1().RequestAuthentications(requestAuthentication.Namespace).Create(ctx, requestAuthentication, v1.CreateOptions{})
+	return err
+}
+
+// UpdateRequestAuthentication fetches the current RequestAuthentication, applies the given modification and writes it
+// back.
+func (c *IstioClient) UpdateRequestAuthentication(ctx context.Context, namespace string, name string, modify func(ra *securityv1.RequestAuthentication) error) error {
+	ra, err := c.clientset.SecurityV1().RequestAuthentications(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	ra = ra.DeepCopy()
+	if err = modify(ra); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.SecurityV1().RequestAuthentications(namespace).Update(ctx, ra, v1.UpdateOptions{})
+	return err
+}
+
+// DeleteRequestAuthentication deletes the RequestAuthentication. An already deleted RequestAuthentication is not
+// considered an error.
+func (c *IstioClient) DeleteRequestAuthentication(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.SecurityV1().RequestAuthentications(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
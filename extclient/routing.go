@@ -0,0 +1,92 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	apinetv1 "istio.io/api/networking/v1"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	"slices"
+	"strings"
+)
+
+// RouteDestination is a host, and optionally a subset of it, a VirtualService routes traffic to.
+type RouteDestination struct {
+	Host   string
+	Subset string
+}
+
+// GetRouteDestinations returns the distinct destinations of all http, tcp and tls routes of the VirtualService, with
+// their hosts as written in the VirtualService.
+func GetRouteDestinations(vs *networkingv1.VirtualService) []RouteDestination {
+	var destinations []RouteDestination
+	for _, route := range vs.Spec.Http {
+		for _, destination := range route.Route {
+			destinations = appendRouteDestination(destinations, destination.GetDestination())
+		}
+	}
+	for _, route := range vs.Spec.Tcp {
+		for _, destination := range route.Route {
+			destinations = appendRouteDestination(destinations, destination.GetDestination())
+		}
+	}
+	for _, route := range vs.Spec.Tls {
+		for _, destination := range route.Route {
+			destinations = appendRouteDestination(destinations, destination.GetDestination())
+		}
+	}
+	return destinations
+}
+
+func appendRouteDestination(destinations []RouteDestination, destination *apinetv1.Destination) []RouteDestination {
+	routeDestination := RouteDestination{Host: destination.GetHost(), Subset: destination.GetSubset()}
+	if routeDestination.Host == "" || slices.Contains(destinations, routeDestination) {
+		return destinations
+	}
+	return append(destinations, routeDestination)
+}
+
+// ToFullyQualifiedHost expands a host referring to a Kubernetes service, resolving short names relative to the given
+// namespace. Other hosts, e.g. of ServiceEntries or wildcards, are returned unchanged. The default cluster domain
+// cluster.local is assumed.
+func ToFullyQualifiedHost(namespace string, host string) string {
+	serviceNamespace, serviceName, ok := toServiceNamespaceAndName(namespace, host)
+	if !ok {
+		return host
+	}
+	return serviceName + "." + serviceNamespace + ".svc.cluster.local"
+}
+
+// HostMatches tells whether the fully qualified host is matched by the fully qualified, possibly wildcard, host of a
+// DestinationRule.
+func HostMatches(pattern string, host string) bool {
+	if pattern == host {
+		return true
+	}
+	return strings.HasPrefix(pattern, "*") && strings.HasSuffix(host, pattern[1:])
+}
+
+// GetDestinationRulesForHost returns the DestinationRules applying to the given fully qualified host.
+func (c *IstioClient) GetDestinationRulesForHost(host string) []*networkingv1.DestinationRule {
+	var result []*networkingv1.DestinationRule
+	for _, destinationRule := range c.GetDestinationRules() {
+		if HostMatches(ToFullyQualifiedHost(destinationRule.Namespace, destinationRule.Spec.Host), host) {
+			result = append(result, destinationRule)
+		}
+	}
+	return result
+}
+
+// GetVirtualServicesForDestinationRule returns the VirtualServices routing to a host the DestinationRule applies to.
+func (c *IstioClient) GetVirtualServicesForDestinationRule(destinationRule *networkingv1.DestinationRule) []*networkingv1.VirtualService {
+	pattern := ToFullyQualifiedHost(destinationRule.Namespace, destinationRule.Spec.Host)
+	var result []*networkingv1.VirtualService
+	for _, virtualService := range c.GetVirtualServices() {
+		if slices.ContainsFunc(GetRouteDestinations(virtualService), func(destination RouteDestination) bool {
+			return HostMatches(pattern, ToFullyQualifiedHost(virtualService.Namespace, destination.Host))
+		}) {
+			result = append(result, virtualService)
+		}
+	}
+	return result
+}
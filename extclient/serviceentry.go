@@ -0,0 +1,26 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// UpdateServiceEntry fetches the current ServiceEntry, applies the given modification and writes it back.
+func (c *IstioClient) UpdateServiceEntry(ctx context.Context, namespace string, name string, modify func(se *networkingv1.ServiceEntry) error) error {
+	se, err := c.clientset.NetworkingV1().ServiceEntries(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	se = se.DeepCopy()
+	if err = modify(se); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.NetworkingV1().ServiceEntries(namespace).Update(ctx, se, v1.UpdateOptions{})
+	return err
+}
@@ -0,0 +1,66 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+)
+
+func (c *IstioClient) ListSidecars(ctx context.Context, namespace string) ([]*networkingv1.Sidecar, error) {
+	list, err := c.clientset.NetworkingV1().Sidecars(namespace).List(ctx, v1.ListOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+func (c *IstioClient) CreateSidecar(ctx context.Context, sidecar *networkingv1.Sidecar) error {
+	_, err := c.clientset.NetworkingV1().Sidecars(sidecar.Namespace).Create(ctx, sidecar, v1.CreateOptions{})
+	return err
+}
+
+// UpdateSidecar fetches the current Sidecar, applies the given modification and writes it back.
+func (c *IstioClient) UpdateSidecar(ctx context.Context, namespace string, name string, modify func(sidecar *networkingv1.Sidecar) error) error {
+	sidecar, err := c.clientset.NetworkingV1().Sidecars(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	sidecar = sidecar.DeepCopy()
+	if err = modify(sidecar); err != nil {
+		return err
+	}
+
+	_, err = c.clientset.NetworkingV1().Sidecars(namespace).Update(ctx, sidecar, v1.UpdateOptions{})
+	return err
+}
+
+// DeleteSidecar deletes the Sidecar. An already deleted Sidecar is not considered an error.
+func (c *IstioClient) DeleteSidecar(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.NetworkingV1().Sidecars(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// GetEgressHosts returns the hosts of all Kubernetes services and ServiceEntries in the egress host format of Sidecars,
+// i.e. namespace/fully qualified host.
+func (c *IstioClient) GetEgressHosts() []string {
+	var hosts []string
+	for _, service := range c.GetServices() {
+		hosts = append(hosts, service.Namespace+"/"+ToFullyQualifiedHost(service.Namespace, service.Name))
+	}
+	for _, serviceEntry := range c.GetServiceEntries() {
+		for _, host := range serviceEntry.Spec.Hosts {
+			hosts = append(hosts, serviceEntry.Namespace+"/"+host)
+		}
+	}
+	slices.Sort(hosts)
+	return slices.Compact(hosts)
+}
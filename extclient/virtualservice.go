@@ -0,0 +1,152 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"context"
+	"fmt"
+	apinetv1 "istio.io/api/networking/v1"
+	networkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// CreateVirtualServiceWithHTTPFault creates a VirtualService for the host, which routes the traffic to the host
+// unchanged, except for the faulty route built like in AddHTTPFault.
+func (c *IstioClient) CreateVirtualServiceWithHTTPFault(ctx context.Context,
+	namespace string,
+	name string,
+	labels map[string]string,
+	host string,
+	faultyRouteNamePrefix string,
+	fault *apinetv1.HTTPFaultInjection, sourceLabels map[string]string, headers map[string]*apinetv1.StringMatch) error {
+
+	httpRouteWithoutFault := &apinetv1.HTTPRoute{
+		Route: []*apinetv1.HTTPRouteDestination{
+			{Destination: &apinetv1.Destination{Host: host}},
+		},
+	}
+	httpRouteWithFault := httpRouteWithoutFault.DeepCopy()
+	httpRouteWithFault.Name = fmt.Sprintf("%s_%d", faultyRouteNamePrefix, 0)
+	addFault(httpRouteWithFault, fault, sourceLabels, headers)
+
+	_, err := c.clientset.NetworkingV1().VirtualServices(namespace).Create(ctx, &networkingv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      name,
+			Namespace: namespace,
+			Labels:    labels,
+		},
+		Spec: apinetv1.VirtualService{
+			Hosts: []string{host},
+			Http:  []*apinetv1.HTTPRoute{httpRouteWithFault, httpRouteWithoutFault},
+		},
+	}, v1.CreateOptions{})
+	return err
+}
+
+// AddHTTPFaultForAuthority adds the fault like AddHTTPFault, but only for requests to the given host. It is meant for
+// VirtualServices covering the host through a wildcard, whose routes apply to other hosts too. Matches already
+// restricted to another authority don't get a faulty copy, as the fault would not be limited to the host anymore.
+func (c *IstioClient) AddHTTPFaultForAuthority(ctx context.Context,
+	namespace string,
+	name string,
+	host string,
+	faultyRouteNamePrefix string,
+	fault *apinetv1.HTTPFaultInjection, sourceLabels map[string]string, headers map[string]*apinetv1.StringMatch) error {
+
+	vs, err := c.clientset.NetworkingV1().VirtualServices(namespace).Get(ctx, name, v1.GetOptions{})
+	if err != nil {
+		return err
+	}
+
+	vs = vs.DeepCopy()
+	httpRoutes := make([]*apinetv1.HTTPRoute, 0, len(vs.Spec.Http)*2)
+	for i, httpRouteWithoutFault := range vs.Spec.Http {
+		// The VirtualService might already carry the faulty routes for other hosts covered by the wildcard.
+		if strings.HasPrefix(httpRouteWithoutFault.Name, faultyRouteNamePrefix) {
+			httpRoutes = append(httpRoutes, httpRouteWithoutFault)
+			continue
+		}
+		httpRouteWithFault := httpRouteWithoutFault.DeepCopy()
+		httpRouteWithFault.Name = fmt.Sprintf("%s_%s_%d", faultyRouteNamePrefix, host, i)
+		if restrictToAuthority(httpRouteWithFault, host) {
+			addFault(httpRouteWithFault, fault, sourceLabels, headers)
+			httpRoutes = append(httpRoutes, httpRouteWithFault)
+		}
+		httpRoutes = append(httpRoutes, httpRouteWithoutFault)
+	}
+	if len(httpRoutes) == len(vs.Spec.Http) {
+		return fmt.Errorf("no HTTP route of VirtualService %s in namespace %s can be restricted to host %s", name, namespace, host)
+	}
+	vs.Spec.Http = httpRoutes
+
+	_, err = c.clientset.NetworkingV1().VirtualServices(namespace).Update(ctx, vs, v1.UpdateOptions{})
+	return err
+}
+
+// restrictToAuthority limits the route to requests for the host, with or without port. Matches restricted to another
+// authority are dropped, false is returned if no match is left.
+func restrictToAuthority(httpRoute *apinetv1.HTTPRoute, host string) bool {
+	authorityRegex := toAuthorityRegex(host)
+	authority := &apinetv1.StringMatch{
+		MatchType: &apinetv1.StringMatch_Regex{Regex: authorityRegex},
+	}
+	if len(httpRoute.Match) == 0 {
+		httpRoute.Match = []*apinetv1.HTTPMatchRequest{{Authority: authority}}
+		return true
+	}
+
+	matchesHost := regexp.MustCompile("^(?:" + authorityRegex + ")$")
+	matches := make([]*apinetv1.HTTPMatchRequest, 0, len(httpRoute.Match))
+	for _, match := range httpRoute.Match {
+		if match.Authority == nil {
+			match.Authority = authority.DeepCopy()
+		} else if !matchesHost.MatchString(match.Authority.GetExact()) {
+			continue
+		}
+		matches = append(matches, match)
+	}
+	httpRoute.Match = matches
+	return len(matches) > 0
+}
+
+// toAuthorityRegex matches the authority of requests for the fully qualified host. Kubernetes services are also
+// requested through their short names, e.g. reviews or reviews.shop for reviews.shop.svc.cluster.local.
+func toAuthorityRegex(host string) string {
+	serviceHost, isService := strings.CutSuffix(host, ".svc.cluster.local")
+	name, namespace, ok := strings.Cut(serviceHost, ".")
+	if !isService || !ok || strings.Contains(namespace, ".") {
+		return regexp.QuoteMeta(host) + "(:[0-9]+)?"
+	}
+	return regexp.QuoteMeta(name) + `(\.` + regexp.QuoteMeta(namespace) + `(\.svc(\.cluster\.local)?)?)?(:[0-9]+)?`
+}
+
+// DeleteVirtualService deletes the VirtualService. An already deleted VirtualService is not considered an error.
+func (c *IstioClient) DeleteVirtualService(ctx context.Context, namespace string, name string) error {
+	err := c.clientset.NetworkingV1().VirtualServices(namespace).Delete(ctx, name, v1.DeleteOptions{})
+	if k8serrors.IsNotFound(err) {
+		return nil
+	}
+	return err
+}
+
+// GetVirtualServicesForHost returns the VirtualServices applying to the traffic of sidecars to the given fully
+// qualified host. VirtualServices bound to gateways only are skipped.
+func (c *IstioClient) GetVirtualServicesForHost(host string) []*networkingv1.VirtualService {
+	var result []*networkingv1.VirtualService
+	for _, virtualService := range c.GetVirtualServices() {
+		if len(virtualService.Spec.Gateways) > 0 && !slices.Contains(virtualService.Spec.Gateways, "mesh") {
+			continue
+		}
+		if slices.ContainsFunc(virtualService.Spec.Hosts, func(virtualServiceHost string) bool {
+			return HostMatches(ToFullyQualifiedHost(virtualService.Namespace, virtualServiceHost), host)
+		}) {
+			result = append(result, virtualService)
+		}
+	}
+	return result
+}
@@ -0,0 +1,81 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extclient
+
+import (
+	"github.com/rs/zerolog/log"
+	"maps"
+	"slices"
+	"strings"
+)
+
+// Workload describes the pods behind a Kubernetes service, selected through the service's label selector.
+type Workload struct {
+	Namespace string
+	Service   string
+	Labels    map[string]string
+}
+
+// GetWorkloads resolves the given mesh hosts to the workloads of the Kubernetes services backing them. Short host
+// names are resolved relative to the given namespace. Hosts which cannot be mapped to a service with a selector are
+// skipped.
+func (c *IstioClient) GetWorkloads(namespace string, hosts []string) []Workload {
+	result := make([]Workload, 0, len(hosts))
+	seen := make(map[string]bool, len(hosts))
+
+	for _, host := range hosts {
+		serviceNamespace, serviceName, ok := toServiceNamespaceAndName(namespace, host)
+		if !ok || seen[serviceNamespace+"/"+serviceName] {
+			continue
+		}
+		seen[serviceNamespace+"/"+serviceName] = true
+
+		service, err := c.servicesLister.Services(serviceNamespace).Get(serviceName)
+		if err != nil {
+			log.Debug().Err(err).Msgf("Failed to resolve host %s to a Kubernetes service", host)
+			continue
+		}
+
+		if len(service.Spec.Selector) == 0 {
+			log.Debug().Msgf("Kubernetes service %s/%s has no selector", serviceNamespace, serviceName)
+			continue
+		}
+
+		result = append(result, Workload{
+			Namespace: service.Namespace,
+			Service:   service.Name,
+			Labels:    service.Spec.Selector,
+		})
+	}
+
+	return result
+}
+
+// toServiceNamespaceAndName maps a host to the Kubernetes service it refers to. Like Istio, only short names without
+// dots are resolved relative to the namespace, other service hosts have to use the <name>.<namespace>.svc form. Hosts
+// like httpbin.org are external and don't refer to a service.
+func toServiceNamespaceAndName(namespace string, host string) (string, string, bool) {
+	if host == "" || strings.Contains(host, "*") {
+		return "", "", false
+	}
+
+	parts := strings.Split(host, ".")
+	if len(parts) == 1 {
+		return namespace, parts[0], true
+	}
+	if len(parts) >= 3 && parts[2] == "svc" {
+		return parts[1], parts[0], true
+	}
+	return "", "", false
+}
+
+// FormatLabels renders workload labels sorted by key, e.g. app=reviews,version=v1.
+func FormatLabels(labels map[string]string) string {
+	keys := slices.Sorted(maps.Keys(labels))
+	pairs := make([]string, len(keys))
+	for i, key := range keys {
+		pairs[i] = key + "=" + labels[key]
+	}
+	return strings.Join(pairs, ",")
+}
@@ -9,8 +9,12 @@ import (
 )
 
 type Specification struct {
-	ClusterName                              string   `required:"true" split_words:"true"`
-	DiscoveryAttributesExcludesVirtualSerice []string `json:"discoveryAttributesExcludesVirtualSerice" split_words:"true" required:"false"`
+	ClusterName                                string   `required:"true" split_words:"true"`
+	DiscoveryAttributesExcludesVirtualSerice   []string `json:"discoveryAttributesExcludesVirtualSerice" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesDestinationRule []string `json:"discoveryAttributesExcludesDestinationRule" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesGateway         []string `json:"discoveryAttributesExcludesGateway" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesServiceEntry    []string `json:"discoveryAttributesExcludesServiceEntry" split_words:"true" required:"false"`
+	DiscoveryAttributesExcludesService         []string `json:"discoveryAttributesExcludesService" split_words:"true" required:"false"`
 }
 
 var (
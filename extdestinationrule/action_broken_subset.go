@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	"maps"
+)
+
+// brokenSubsetLabel is added to the label selector of the attacked subset. No pod carries it, so the subset selects
+// no endpoints.
+const brokenSubsetLabel = "steadybit.com/broken-subset"
+
+type BrokenSubsetAction struct {
+}
+
+func NewBrokenSubsetAction() action_kit_sdk.Action[ActionState] {
+	return BrokenSubsetAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*BrokenSubsetAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*BrokenSubsetAction)(nil)
+
+func (f BrokenSubsetAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f BrokenSubsetAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.broken-subset", DestinationRuleTargetID),
+		Label:           "Break Subset",
+		Description:     "Changes the label selector of a subset of the targeted destination rules so that it matches no pods, like a typo in the subset labels during a deployment. Requests routed to the subset fail with 503 (NR/UH).",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the subset should be broken."),
+			{
+				Name:        "subset",
+				Label:       "Subset",
+				Description: new("Name of the subset whose label selector should match no pods."),
+				Type:        action_kit_api.ActionParameterTypeString,
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ParameterOptionsFromTargetAttribute{
+						Attribute: "istio.destination-rule.subset",
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f BrokenSubsetAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.Subset = extutil.ToString(request.Config["subset"])
+	if state.Subset == "" {
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("subset is required"))
+	}
+
+	setDestinationRule(state, request)
+	return nil, nil
+}
+
+func (f BrokenSubsetAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, breakSubset)
+}
+
+func (f BrokenSubsetAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+// breakSubset keeps the original labels of the subset and adds one no pod carries, the exact subset definition is
+// restored from the snapshot on stop.
+func breakSubset(state *ActionState, spec *networkingv1.DestinationRule) error {
+	for _, subset := range spec.Subsets {
+		if subset.Name == state.Subset {
+			labels := maps.Clone(subset.Labels)
+			if labels == nil {
+				labels = make(map[string]string)
+			}
+			labels[brokenSubsetLabel] = "true"
+			subset.Labels = labels
+			return nil
+		}
+	}
+	return fmt.Errorf("subset %s not found", state.Subset)
+}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	networkingv1 "istio.io/api/networking/v1"
+)
+
+type CircuitBreakerAction struct {
+}
+
+func NewCircuitBreakerAction() action_kit_sdk.Action[ActionState] {
+	return CircuitBreakerAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*CircuitBreakerAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*CircuitBreakerAction)(nil)
+
+func (f CircuitBreakerAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f CircuitBreakerAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.circuit-breaker", DestinationRuleTargetID),
+		Label:           "Tighten Circuit Breaker",
+		Description:     "Lowers the connection pool limits of the targeted destination rules, so that Envoy overflows the pool and answers with 503 (UO) responses, tripping the mesh circuit breaker.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the connection pool limits should be lowered."),
+			{
+				Name:         "maxConnections",
+				Label:        "Max connections",
+				Description:  new("Maximum number of HTTP1/TCP connections to a destination host."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "http1MaxPendingRequests",
+				Label:        "Max pending HTTP1 requests",
+				Description:  new("Maximum number of requests that will be queued while waiting for a ready connection pool connection."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "http2MaxRequests",
+				Label:        "Max HTTP2 requests",
+				Description:  new("Maximum number of active requests to a destination."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f CircuitBreakerAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toCircuitBreakerTrafficPolicy)
+}
+
+func (f CircuitBreakerAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, applyConnectionPool)
+}
+
+func (f CircuitBreakerAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toCircuitBreakerTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	return &networkingv1.TrafficPolicy{
+		ConnectionPool: &networkingv1.ConnectionPoolSettings{
+			Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+				MaxConnections: extutil.ToInt32(request.Config["maxConnections"]),
+			},
+			Http: &networkingv1.ConnectionPoolSettings_HTTPSettings{
+				Http1MaxPendingRequests: extutil.ToInt32(request.Config["http1MaxPendingRequests"]),
+				Http2MaxRequests:        extutil.ToInt32(request.Config["http2MaxRequests"]),
+			},
+		},
+	}, nil
+}
+
+// applyConnectionPool sets the connection pool settings of the attack, keeping all settings not touched by the attack.
+func applyConnectionPool(state *ActionState, spec *networkingv1.DestinationRule) error {
+	connectionPool := state.TrafficPolicy.ConnectionPool
+	forEachTrafficPolicy(spec, func(trafficPolicy *networkingv1.TrafficPolicy) {
+		if trafficPolicy.ConnectionPool == nil {
+			trafficPolicy.ConnectionPool = &networkingv1.ConnectionPoolSettings{}
+		}
+		if connectionPool.Tcp != nil {
+			if trafficPolicy.ConnectionPool.Tcp == nil {
+				trafficPolicy.ConnectionPool.Tcp = &networkingv1.ConnectionPoolSettings_TCPSettings{}
+			}
+			overwriteFields(trafficPolicy.ConnectionPool.Tcp, connectionPool.Tcp)
+		}
+		if connectionPool.Http != nil {
+			if trafficPolicy.ConnectionPool.Http == nil {
+				trafficPolicy.ConnectionPool.Http = &networkingv1.ConnectionPoolSettings_HTTPSettings{}
+			}
+			overwriteFields(trafficPolicy.ConnectionPool.Http, connectionPool.Http)
+		}
+	})
+	return nil
+}
+
+// overwriteFields sets all populated fields of src on dst. Unlike proto.Merge, message fields like durations are
+// replaced as a whole instead of being merged field by field.
+func overwriteFields(dst proto.Message, src proto.Message) {
+	proto.Clone(src).ProtoReflect().Range(func(field protoreflect.FieldDescriptor, value protoreflect.Value) bool {
+		dst.ProtoReflect().Set(field, value)
+		return true
+	})
+}
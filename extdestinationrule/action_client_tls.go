@@ -0,0 +1,144 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	"strings"
+)
+
+type ClientTLSAction struct {
+}
+
+func NewClientTLSAction() action_kit_sdk.Action[ActionState] {
+	return ClientTLSAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*ClientTLSAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*ClientTLSAction)(nil)
+
+func (f ClientTLSAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f ClientTLSAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.client-tls", DestinationRuleTargetID),
+		Label:           "Misconfigure mTLS",
+		Description:     "Sets the client TLS mode of the targeted destination rules to one conflicting with the server side, e.g. plaintext against a STRICT PeerAuthentication or simple TLS with a bogus SNI, so that connections to the destination hosts fail.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the TLS settings should be misconfigured."),
+			{
+				Name:         "mode",
+				Label:        "TLS mode",
+				Description:  new("Client TLS mode used for connections to the destination hosts."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(networkingv1.ClientTLSSettings_DISABLE.String()),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Disable (plaintext)",
+						Value: networkingv1.ClientTLSSettings_DISABLE.String(),
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Simple TLS",
+						Value: networkingv1.ClientTLSSettings_SIMPLE.String(),
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+			{
+				Name:         "sni",
+				Label:        "SNI",
+				Description:  new("Server name presented during the TLS handshake. Only used for the mode 'Simple TLS'."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new("invalid.steadybit.local"),
+				Required:     new(false),
+				Order:        new(2),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f ClientTLSAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toClientTLSTrafficPolicy)
+}
+
+func (f ClientTLSAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	var affectedHosts string
+	err := startDestinationRuleChange(ctx, state, func(state *ActionState, spec *networkingv1.DestinationRule) error {
+		affectedHosts = describeAffectedHosts(spec)
+		return applyClientTLS(state, spec)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{
+			{
+				Level:   new(action_kit_api.Info),
+				Message: fmt.Sprintf("Set TLS mode of DestinationRule %s in namespace %s to %s, affecting %s.", state.Name, state.Namespace, state.TrafficPolicy.Tls.GetMode(), affectedHosts),
+			},
+		},
+	}, nil
+}
+
+func (f ClientTLSAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toClientTLSTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	tls := &networkingv1.ClientTLSSettings{}
+	switch mode := extutil.ToString(request.Config["mode"]); mode {
+	case networkingv1.ClientTLSSettings_DISABLE.String():
+		tls.Mode = networkingv1.ClientTLSSettings_DISABLE
+	case networkingv1.ClientTLSSettings_SIMPLE.String():
+		tls.Mode = networkingv1.ClientTLSSettings_SIMPLE
+		tls.Sni = extutil.ToString(request.Config["sni"])
+	default:
+		return nil, fmt.Errorf("unknown TLS mode '%s'", mode)
+	}
+
+	return &networkingv1.TrafficPolicy{
+		Tls: tls,
+	}, nil
+}
+
+// applyClientTLS replaces the TLS settings as a whole. The settings can't be merged, as DISABLE is the zero value of
+// the TLS mode.
+func applyClientTLS(state *ActionState, spec *networkingv1.DestinationRule) error {
+	forEachTrafficPolicy(spec, func(trafficPolicy *networkingv1.TrafficPolicy) {
+		trafficPolicy.Tls = state.TrafficPolicy.Tls.DeepCopy()
+	})
+	return nil
+}
+
+// describeAffectedHosts names the destination host of the DestinationRule and its subsets, whose connections are
+// affected by the attack.
+func describeAffectedHosts(spec *networkingv1.DestinationRule) string {
+	if len(spec.Subsets) == 0 {
+		return fmt.Sprintf("host %s", spec.Host)
+	}
+	subsets := make([]string, len(spec.Subsets))
+	for i, subset := range spec.Subsets {
+		subsets[i] = subset.Name
+	}
+	return fmt.Sprintf("host %s (subsets %s)", spec.Host, strings.Join(subsets, ", "))
+}
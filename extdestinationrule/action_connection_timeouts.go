@@ -0,0 +1,117 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"google.golang.org/protobuf/types/known/durationpb"
+	networkingv1 "istio.io/api/networking/v1"
+	"time"
+)
+
+type ConnectionTimeoutsAction struct {
+}
+
+func NewConnectionTimeoutsAction() action_kit_sdk.Action[ActionState] {
+	return ConnectionTimeoutsAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*ConnectionTimeoutsAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*ConnectionTimeoutsAction)(nil)
+
+func (f ConnectionTimeoutsAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f ConnectionTimeoutsAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.connection-timeouts", DestinationRuleTargetID),
+		Label:           "Hostile Connection Timeouts",
+		Description:     "Sets hostile connect and idle timeouts and limits the requests per connection in the connection pool of the targeted destination rules, causing connect failures and connection churn, e.g. for clients relying on long-lived gRPC connections.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the connection timeouts should be applied."),
+			{
+				Name:         "connectTimeout",
+				Label:        "TCP connect timeout",
+				Description:  new("TCP connection timeout for connections to a destination host."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("10ms"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "tcpIdleTimeout",
+				Label:        "TCP idle timeout",
+				Description:  new("Time after which idle TCP connections are closed."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1s"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "httpIdleTimeout",
+				Label:        "HTTP idle timeout",
+				Description:  new("Time after which upstream connections without active requests are closed."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1s"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(3),
+			},
+			{
+				Name:         "maxRequestsPerConnection",
+				Label:        "Max requests per connection",
+				Description:  new("Maximum number of requests per connection to a destination host. A value of 1 disables keep-alive."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(4),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f ConnectionTimeoutsAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toConnectionTimeoutsTrafficPolicy)
+}
+
+func (f ConnectionTimeoutsAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, applyConnectionPool)
+}
+
+func (f ConnectionTimeoutsAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toConnectionTimeoutsTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	return &networkingv1.TrafficPolicy{
+		ConnectionPool: &networkingv1.ConnectionPoolSettings{
+			Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+				ConnectTimeout: durationpb.New(time.Millisecond * time.Duration(extutil.ToInt64(request.Config["connectTimeout"]))),
+				IdleTimeout:    durationpb.New(time.Millisecond * time.Duration(extutil.ToInt64(request.Config["tcpIdleTimeout"]))),
+			},
+			Http: &networkingv1.ConnectionPoolSettings_HTTPSettings{
+				IdleTimeout:              durationpb.New(time.Millisecond * time.Duration(extutil.ToInt64(request.Config["httpIdleTimeout"]))),
+				MaxRequestsPerConnection: extutil.ToInt32(request.Config["maxRequestsPerConnection"]),
+			},
+		},
+	}, nil
+}
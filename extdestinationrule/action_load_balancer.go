@@ -0,0 +1,128 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	"slices"
+)
+
+// loadBalancerPolicies are the simple load balancer policies the attack can switch to.
+var loadBalancerPolicies = []networkingv1.LoadBalancerSettings_SimpleLB{
+	networkingv1.LoadBalancerSettings_RANDOM,
+	networkingv1.LoadBalancerSettings_ROUND_ROBIN,
+	networkingv1.LoadBalancerSettings_LEAST_REQUEST,
+	networkingv1.LoadBalancerSettings_PASSTHROUGH,
+}
+
+type LoadBalancerAction struct {
+}
+
+func NewLoadBalancerAction() action_kit_sdk.Action[ActionState] {
+	return LoadBalancerAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*LoadBalancerAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*LoadBalancerAction)(nil)
+
+func (f LoadBalancerAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f LoadBalancerAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.load-balancer", DestinationRuleTargetID),
+		Label:           "Swap Load Balancer Policy",
+		Description:     "Replaces the load balancer policy of the targeted destination rules, e.g. dropping a consistent hash based session affinity, to reveal services silently relying on sticky routing.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the load balancer policy should be replaced."),
+			{
+				Name:         "policy",
+				Label:        "Load balancer policy",
+				Description:  new("Load balancer policy used instead of the configured one. Locality and warmup settings are kept."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(networkingv1.LoadBalancerSettings_RANDOM.String()),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Random",
+						Value: networkingv1.LoadBalancerSettings_RANDOM.String(),
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Round robin",
+						Value: networkingv1.LoadBalancerSettings_ROUND_ROBIN.String(),
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Least request",
+						Value: networkingv1.LoadBalancerSettings_LEAST_REQUEST.String(),
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Passthrough",
+						Value: networkingv1.LoadBalancerSettings_PASSTHROUGH.String(),
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f LoadBalancerAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toLoadBalancerTrafficPolicy)
+}
+
+func (f LoadBalancerAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, applyLoadBalancerPolicy)
+}
+
+func (f LoadBalancerAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toLoadBalancerTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	policy := extutil.ToString(request.Config["policy"])
+	index := slices.IndexFunc(loadBalancerPolicies, func(p networkingv1.LoadBalancerSettings_SimpleLB) bool {
+		return p.String() == policy
+	})
+	if index < 0 {
+		return nil, fmt.Errorf("unknown load balancer policy '%s'", policy)
+	}
+
+	return &networkingv1.TrafficPolicy{
+		LoadBalancer: &networkingv1.LoadBalancerSettings{
+			LbPolicy: &networkingv1.LoadBalancerSettings_Simple{
+				Simple: loadBalancerPolicies[index],
+			},
+		},
+	}, nil
+}
+
+// applyLoadBalancerPolicy replaces only the load balancer policy, a simple policy and a consistent hash are mutually
+// exclusive, so the latter is dropped.
+func applyLoadBalancerPolicy(state *ActionState, spec *networkingv1.DestinationRule) error {
+	forEachTrafficPolicy(spec, func(trafficPolicy *networkingv1.TrafficPolicy) {
+		if trafficPolicy.LoadBalancer == nil {
+			trafficPolicy.LoadBalancer = &networkingv1.LoadBalancerSettings{}
+		}
+		trafficPolicy.LoadBalancer.LbPolicy = &networkingv1.LoadBalancerSettings_Simple{
+			Simple: state.TrafficPolicy.LoadBalancer.GetSimple(),
+		}
+	})
+	return nil
+}
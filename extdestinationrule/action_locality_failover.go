@@ -0,0 +1,169 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	networkingv1 "istio.io/api/networking/v1"
+	"time"
+)
+
+const (
+	localityModeDistribute       = "distribute"
+	localityModeFailoverPriority = "failoverPriority"
+)
+
+type LocalityFailoverAction struct {
+}
+
+func NewLocalityFailoverAction() action_kit_sdk.Action[ActionState] {
+	return LocalityFailoverAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*LocalityFailoverAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*LocalityFailoverAction)(nil)
+
+func (f LocalityFailoverAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f LocalityFailoverAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.locality-failover", DestinationRuleTargetID),
+		Label:           "Force Locality Failover",
+		Description:     "Replaces the locality load balancer settings of the targeted destination rules to force traffic into another zone or region, verifying cross-zone failover latency and capacity without draining a zone. Either all traffic of clients in one locality is distributed to another locality, or the endpoints are prioritized by the given failover priority labels instead of the client's locality. Both take effect while the endpoints are healthy. Outlier detection, required by Istio for locality load balancing, is added when missing.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the locality load balancer settings should be replaced."),
+			{
+				Name:         "mode",
+				Label:        "Mode",
+				Description:  new("How traffic is moved to the other locality."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(localityModeDistribute),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Distribute all traffic from 'From' to 'To'",
+						Value: localityModeDistribute,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Prioritize endpoints by failover priority labels",
+						Value: localityModeFailoverPriority,
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+			{
+				Name:         "from",
+				Label:        "From",
+				Description:  new("Locality of the clients whose traffic is moved, e.g. 'us-west/zone1/*', or '*' for all clients. Only used for the mode 'Distribute all traffic from 'From' to 'To''."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new("*"),
+				Required:     new(false),
+				Order:        new(2),
+			},
+			{
+				Name:        "to",
+				Label:       "To",
+				Description: new("Locality receiving all the traffic, e.g. 'us-west/zone2/*'. Only used for the mode 'Distribute all traffic from 'From' to 'To''."),
+				Type:        action_kit_api.ActionParameterTypeString,
+				Required:    new(false),
+				Order:       new(3),
+			},
+			{
+				Name:        "failoverPriority",
+				Label:       "Failover priority",
+				Description: new("Ordered labels used to prioritize endpoints matching the labels of the client, e.g. 'topology.istio.io/network' or 'topology.kubernetes.io/region'. Only used for the mode 'Prioritize endpoints by failover priority labels'."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
+				Order:       new(4),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f LocalityFailoverAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toLocalityFailoverTrafficPolicy)
+}
+
+func (f LocalityFailoverAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, applyLocalityLbSetting)
+}
+
+func (f LocalityFailoverAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toLocalityFailoverTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	localityLbSetting := &networkingv1.LocalityLoadBalancerSetting{
+		Enabled: wrapperspb.Bool(true),
+	}
+
+	switch mode := extutil.ToString(request.Config["mode"]); mode {
+	case localityModeDistribute:
+		from := extutil.ToString(request.Config["from"])
+		to := extutil.ToString(request.Config["to"])
+		if from == "" || to == "" {
+			return nil, errors.New("'From' and 'To' are required to distribute traffic")
+		}
+		localityLbSetting.Distribute = []*networkingv1.LocalityLoadBalancerSetting_Distribute{
+			{
+				From: from,
+				To:   map[string]uint32{to: 100},
+			},
+		}
+	case localityModeFailoverPriority:
+		localityLbSetting.FailoverPriority = extutil.ToStringArray(request.Config["failoverPriority"])
+		if len(localityLbSetting.FailoverPriority) == 0 {
+			return nil, errors.New("at least one failover priority label is required")
+		}
+	default:
+		return nil, fmt.Errorf("unknown mode '%s'", mode)
+	}
+
+	return &networkingv1.TrafficPolicy{
+		LoadBalancer: &networkingv1.LoadBalancerSettings{
+			LocalityLbSetting: localityLbSetting,
+		},
+		// Envoy's defaults, only used when the DestinationRule has no outlier detection of its own.
+		OutlierDetection: &networkingv1.OutlierDetection{
+			Consecutive_5XxErrors: wrapperspb.UInt32(5),
+			Interval:              durationpb.New(10 * time.Second),
+			BaseEjectionTime:      durationpb.New(30 * time.Second),
+		},
+	}, nil
+}
+
+// applyLocalityLbSetting replaces the locality load balancer settings as a whole, keeping the load balancer policy.
+// Istio ignores locality failover without outlier detection, hence it is added to the top-level traffic policy when
+// missing. Subsets inherit it from there, unless they define their own.
+func applyLocalityLbSetting(state *ActionState, spec *networkingv1.DestinationRule) error {
+	forEachTrafficPolicy(spec, func(trafficPolicy *networkingv1.TrafficPolicy) {
+		if trafficPolicy.LoadBalancer == nil {
+			trafficPolicy.LoadBalancer = &networkingv1.LoadBalancerSettings{}
+		}
+		trafficPolicy.LoadBalancer.LocalityLbSetting = state.TrafficPolicy.LoadBalancer.LocalityLbSetting.DeepCopy()
+	})
+	if spec.TrafficPolicy.OutlierDetection == nil {
+		spec.TrafficPolicy.OutlierDetection = state.TrafficPolicy.OutlierDetection.DeepCopy()
+	}
+	return nil
+}
@@ -0,0 +1,122 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	networkingv1 "istio.io/api/networking/v1"
+	"time"
+)
+
+type OutlierDetectionAction struct {
+}
+
+func NewOutlierDetectionAction() action_kit_sdk.Action[ActionState] {
+	return OutlierDetectionAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*OutlierDetectionAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*OutlierDetectionAction)(nil)
+
+func (f OutlierDetectionAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f OutlierDetectionAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.outlier-detection", DestinationRuleTargetID),
+		Label:           "Aggressive Outlier Detection",
+		Description:     "Replaces the outlier detection of the targeted destination rules with an extreme one. Together with normal background errors, most endpoints get ejected, showing whether a misconfigured outlier policy can cause a self-inflicted outage.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the aggressive outlier detection should be active."),
+			{
+				Name:         "consecutive5xxErrors",
+				Label:        "Consecutive 5xx errors",
+				Description:  new("Number of 5xx errors before a host is ejected from the connection pool."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "interval",
+				Label:        "Interval",
+				Description:  new("Time interval between ejection sweep analysis."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1s"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "baseEjectionTime",
+				Label:        "Base ejection time",
+				Description:  new("Minimum ejection duration. A host remains ejected for a period equal to the product of the base ejection time and the number of times it has been ejected."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("5m"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(3),
+			},
+			{
+				Name:         "maxEjectionPercent",
+				Label:        "Max ejection percentage",
+				Description:  new("Maximum percentage of hosts in the load balancing pool that can be ejected."),
+				Type:         action_kit_api.ActionParameterTypePercentage,
+				DefaultValue: new("100"),
+				Required:     new(true),
+				Order:        new(4),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f OutlierDetectionAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareDestinationRuleChange(state, request, toOutlierDetectionTrafficPolicy)
+}
+
+func (f OutlierDetectionAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startDestinationRuleChange(ctx, state, applyOutlierDetection)
+}
+
+func (f OutlierDetectionAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopDestinationRuleChange(ctx, state)
+}
+
+func toOutlierDetectionTrafficPolicy(request action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error) {
+	return &networkingv1.TrafficPolicy{
+		OutlierDetection: &networkingv1.OutlierDetection{
+			Consecutive_5XxErrors: wrapperspb.UInt32(uint32(extutil.ToUInt(request.Config["consecutive5xxErrors"]))),
+			Interval:              durationpb.New(time.Millisecond * time.Duration(extutil.ToInt64(request.Config["interval"]))),
+			BaseEjectionTime:      durationpb.New(time.Millisecond * time.Duration(extutil.ToInt64(request.Config["baseEjectionTime"]))),
+			MaxEjectionPercent:    extutil.ToInt32(request.Config["maxEjectionPercent"]),
+		},
+	}, nil
+}
+
+// applyOutlierDetection replaces the outlier detection as a whole, so that no existing setting (e.g. a minimum health
+// percentage) softens the attack.
+func applyOutlierDetection(state *ActionState, spec *networkingv1.DestinationRule) error {
+	forEachTrafficPolicy(spec, func(trafficPolicy *networkingv1.TrafficPolicy) {
+		trafficPolicy.OutlierDetection = state.TrafficPolicy.OutlierDetection.DeepCopy()
+	})
+	return nil
+}
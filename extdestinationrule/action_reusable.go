@@ -0,0 +1,166 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+)
+
+type ActionState struct {
+	ExecutionId string
+	Namespace   string
+	Name        string
+	// TrafficPolicy holds the settings which are applied to the DestinationRule's traffic policies during the attack.
+	TrafficPolicy *networkingv1.TrafficPolicy
+	// Subset is the name of the subset modified by attacks targeting a single subset.
+	Subset string
+	// Applied tells whether the attack modified the DestinationRule, i.e. whether the original settings must be restored.
+	Applied               bool
+	OriginalTrafficPolicy *networkingv1.TrafficPolicy
+	OriginalSubsets       []*networkingv1.Subset
+}
+
+func getDurationParameter(description string) action_kit_api.ActionParameter {
+	return action_kit_api.ActionParameter{
+		Name:         "duration",
+		Label:        "Duration",
+		Description:  new(description),
+		Type:         action_kit_api.ActionParameterTypeDuration,
+		DefaultValue: new("30s"),
+		Required:     new(true),
+		Order:        new(0),
+	}
+}
+
+func getTargetSelection() *action_kit_api.TargetSelection {
+	return new(action_kit_api.TargetSelection{
+		TargetType: DestinationRuleTargetID,
+		SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+			{
+				Label: "name",
+				Query: "istio.destination-rule.name=\"\"",
+			},
+		}),
+	})
+}
+
+func prepareDestinationRuleChange(state *ActionState,
+	request action_kit_api.PrepareActionRequestBody,
+	toTrafficPolicy func(req action_kit_api.PrepareActionRequestBody) (*networkingv1.TrafficPolicy, error)) error {
+
+	trafficPolicy, err := toTrafficPolicy(request)
+	if err != nil {
+		return extension_kit.ToError("Failed prepare attack", err)
+	}
+
+	setDestinationRule(state, request)
+	state.TrafficPolicy = trafficPolicy
+	return nil
+}
+
+func setDestinationRule(state *ActionState, request action_kit_api.PrepareActionRequestBody) {
+	state.ExecutionId = request.ExecutionId.String()
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["istio.destination-rule.name"][0]
+}
+
+// startDestinationRuleChange snapshots the traffic policy and subsets of the DestinationRule into the state before
+// applying the change, so that they can be restored exactly on stop. The DestinationRule is marked as modified, to
+// refuse overlapping attacks on it.
+func startDestinationRuleChange(ctx context.Context, state *ActionState, apply func(state *ActionState, spec *networkingv1.DestinationRule) error) error {
+	err := extclient.Istio.UpdateDestinationRule(ctx, state.Namespace, state.Name, func(dr *apinetworkingv1.DestinationRule) error {
+		if err := extclient.MarkModified(dr, state.ExecutionId); err != nil {
+			return err
+		}
+		state.OriginalTrafficPolicy = dr.Spec.TrafficPolicy.DeepCopy()
+		state.OriginalSubsets = cloneSubsets(dr.Spec.Subsets)
+		return apply(state, &dr.Spec)
+	})
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to modify DestinationRule %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = true
+	return nil
+}
+
+func stopDestinationRuleChange(ctx context.Context, state *ActionState) error {
+	if !state.Applied {
+		return nil
+	}
+
+	err := extclient.Istio.UpdateDestinationRule(ctx, state.Namespace, state.Name, func(dr *apinetworkingv1.DestinationRule) error {
+		dr.Spec.TrafficPolicy = state.OriginalTrafficPolicy.DeepCopy()
+		dr.Spec.Subsets = cloneSubsets(state.OriginalSubsets)
+		extclient.UnmarkModified(dr, state.ExecutionId)
+		return nil
+	})
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to restore DestinationRule %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = false
+	return nil
+}
+
+// forEachTrafficPolicy calls the given function for the top-level traffic policy, which is created when missing, and
+// for all traffic policies of subsets, as these override the top-level settings. The port level settings of these
+// traffic policies override them in turn, so they are visited too.
+func forEachTrafficPolicy(spec *networkingv1.DestinationRule, f func(trafficPolicy *networkingv1.TrafficPolicy)) {
+	if spec.TrafficPolicy == nil {
+		spec.TrafficPolicy = &networkingv1.TrafficPolicy{}
+	}
+	withPortLevelSettings(spec.TrafficPolicy, f)
+
+	for _, subset := range spec.Subsets {
+		if subset.TrafficPolicy != nil {
+			withPortLevelSettings(subset.TrafficPolicy, f)
+		}
+	}
+}
+
+// withPortLevelSettings calls the given function for the traffic policy and for each of its port level settings,
+// passed as a traffic policy of their own. A port only takes over the settings it already overrides, the others are
+// inherited from the modified traffic policy.
+func withPortLevelSettings(trafficPolicy *networkingv1.TrafficPolicy, f func(trafficPolicy *networkingv1.TrafficPolicy)) {
+	f(trafficPolicy)
+
+	for _, portLevelSettings := range trafficPolicy.PortLevelSettings {
+		portTrafficPolicy := &networkingv1.TrafficPolicy{
+			LoadBalancer:     portLevelSettings.LoadBalancer,
+			ConnectionPool:   portLevelSettings.ConnectionPool,
+			OutlierDetection: portLevelSettings.OutlierDetection,
+			Tls:              portLevelSettings.Tls,
+		}
+		f(portTrafficPolicy)
+
+		if portLevelSettings.LoadBalancer != nil {
+			portLevelSettings.LoadBalancer = portTrafficPolicy.LoadBalancer
+		}
+		if portLevelSettings.ConnectionPool != nil {
+			portLevelSettings.ConnectionPool = portTrafficPolicy.ConnectionPool
+		}
+		if portLevelSettings.OutlierDetection != nil {
+			portLevelSettings.OutlierDetection = portTrafficPolicy.OutlierDetection
+		}
+		if portLevelSettings.Tls != nil {
+			portLevelSettings.Tls = portTrafficPolicy.Tls
+		}
+	}
+}
+
+func cloneSubsets(subsets []*networkingv1.Subset) []*networkingv1.Subset {
+	if subsets == nil {
+		return nil
+	}
+	result := make([]*networkingv1.Subset, len(subsets))
+	for i, subset := range subsets {
+		result[i] = subset.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,642 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/wrapperspb"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func Test_circuitBreakerLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			ConnectionPool: &networkingv1.ConnectionPoolSettings{
+				Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+					MaxConnections: 100,
+				},
+				Http: &networkingv1.ConnectionPoolSettings_HTTPSettings{
+					MaxRetries: 3,
+				},
+			},
+		},
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+			},
+			{
+				Name:   "v2",
+				Labels: map[string]string{"version": "v2"},
+				TrafficPolicy: &networkingv1.TrafficPolicy{
+					ConnectionPool: &networkingv1.ConnectionPoolSettings{
+						Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+							MaxConnections: 50,
+						},
+					},
+				},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, CircuitBreakerAction{}, map[string]any{
+		"maxConnections":          1.0,
+		"http1MaxPendingRequests": 2.0,
+		"http2MaxRequests":        3.0,
+	})
+
+	// Start call
+	_, err := CircuitBreakerAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the connection pool limits were lowered, for the top-level and subset traffic policies
+	dr := getDestinationRule(t, clientset)
+	require.Equal(t, int32(1), dr.Spec.TrafficPolicy.ConnectionPool.Tcp.MaxConnections)
+	require.Equal(t, int32(2), dr.Spec.TrafficPolicy.ConnectionPool.Http.Http1MaxPendingRequests)
+	require.Equal(t, int32(3), dr.Spec.TrafficPolicy.ConnectionPool.Http.Http2MaxRequests)
+	require.Equal(t, int32(3), dr.Spec.TrafficPolicy.ConnectionPool.Http.MaxRetries)
+	require.Nil(t, dr.Spec.Subsets[0].TrafficPolicy)
+	require.Equal(t, int32(1), dr.Spec.Subsets[1].TrafficPolicy.ConnectionPool.Tcp.MaxConnections)
+
+	// Stop call
+	_, err = CircuitBreakerAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_circuitBreakerAppliesToPortLevelSettings(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			PortLevelSettings: []*networkingv1.TrafficPolicy_PortTrafficPolicy{
+				{
+					Port: &networkingv1.PortSelector{Number: 9080},
+					ConnectionPool: &networkingv1.ConnectionPoolSettings{
+						Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+							MaxConnections: 100,
+						},
+					},
+				},
+				{
+					Port: &networkingv1.PortSelector{Number: 9090},
+					LoadBalancer: &networkingv1.LoadBalancerSettings{
+						LbPolicy: &networkingv1.LoadBalancerSettings_Simple{Simple: networkingv1.LoadBalancerSettings_ROUND_ROBIN},
+					},
+				},
+			},
+		},
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+				TrafficPolicy: &networkingv1.TrafficPolicy{
+					PortLevelSettings: []*networkingv1.TrafficPolicy_PortTrafficPolicy{
+						{
+							Port: &networkingv1.PortSelector{Number: 9080},
+							ConnectionPool: &networkingv1.ConnectionPoolSettings{
+								Http: &networkingv1.ConnectionPoolSettings_HTTPSettings{
+									MaxRetries: 3,
+								},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, CircuitBreakerAction{}, map[string]any{
+		"maxConnections":          1.0,
+		"http1MaxPendingRequests": 2.0,
+		"http2MaxRequests":        3.0,
+	})
+
+	// Start call
+	_, err := CircuitBreakerAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the ports overriding the connection pool were tightened too, the others still inherit it
+	dr := getDestinationRule(t, clientset)
+	require.Equal(t, int32(1), dr.Spec.TrafficPolicy.ConnectionPool.Tcp.MaxConnections)
+	require.Equal(t, int32(1), dr.Spec.TrafficPolicy.PortLevelSettings[0].ConnectionPool.Tcp.MaxConnections)
+	require.Nil(t, dr.Spec.TrafficPolicy.PortLevelSettings[1].ConnectionPool)
+	subsetConnectionPool := dr.Spec.Subsets[0].TrafficPolicy.PortLevelSettings[0].ConnectionPool
+	require.Equal(t, int32(1), subsetConnectionPool.Tcp.MaxConnections)
+	require.Equal(t, int32(2), subsetConnectionPool.Http.Http1MaxPendingRequests)
+	require.Equal(t, int32(3), subsetConnectionPool.Http.MaxRetries)
+
+	// Stop call
+	_, err = CircuitBreakerAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_overlappingAttacksAreRefused(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Start the first attack
+	state := prepareTestState(t, CircuitBreakerAction{}, map[string]any{
+		"maxConnections":          1.0,
+		"http1MaxPendingRequests": 2.0,
+		"http2MaxRequests":        3.0,
+	})
+	_, err := CircuitBreakerAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+	require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", getDestinationRule(t, clientset).Annotations[extclient.ModifiedByAnnotation])
+
+	// Check that a second attack on the same DestinationRule is refused
+	otherState := prepareTestState(t, CircuitBreakerAction{}, map[string]any{
+		"maxConnections":          10.0,
+		"http1MaxPendingRequests": 20.0,
+		"http2MaxRequests":        30.0,
+	})
+	otherState.ExecutionId = "e5b4a0a4-2b8f-4b59-8f7e-1a8f4c3d9a11"
+	_, err = CircuitBreakerAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+	_, err = CircuitBreakerAction{}.Stop(context.Background(), &otherState)
+	require.NoError(t, err)
+
+	// Stop the first attack
+	_, err = CircuitBreakerAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the original settings were restored and the mark removed
+	requireSpecRestored(t, clientset, originalSpec)
+	require.Empty(t, getDestinationRule(t, clientset).Annotations)
+}
+
+func createDestinationRule(t *testing.T, clientset versionedClient.Interface, spec *networkingv1.DestinationRule) {
+	_, err := clientset.
+		NetworkingV1().
+		DestinationRules("default").
+		Create(context.Background(), &apinetworkingv1.DestinationRule{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "reviews",
+				Namespace: "default",
+			},
+			Spec: *spec.DeepCopy(),
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func getDestinationRule(t *testing.T, clientset versionedClient.Interface) *apinetworkingv1.DestinationRule {
+	dr, err := clientset.
+		NetworkingV1().
+		DestinationRules("default").
+		Get(context.Background(), "reviews", v1.GetOptions{})
+	require.NoError(t, err)
+	return dr
+}
+
+func requireSpecRestored(t *testing.T, clientset versionedClient.Interface, originalSpec *networkingv1.DestinationRule) {
+	dr := getDestinationRule(t, clientset)
+	require.True(t, proto.Equal(originalSpec, &dr.Spec), "expected %v, got %v", originalSpec, &dr.Spec)
+}
+
+// prepareTestState runs the prepare call of the action and sends the state through JSON, like the action SDK does.
+func prepareTestState(t *testing.T, action action_kit_sdk.Action[ActionState], config map[string]any) ActionState {
+	state := action.NewEmptyState()
+	_, err := action.Prepare(context.Background(), &state, extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":               {"default"},
+				"istio.destination-rule.name": {"reviews"},
+			},
+		},
+		Config: config,
+	}))
+	require.NoError(t, err)
+	return extutil.JsonMangle(state)
+}
+
+func Test_outlierDetectionLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			OutlierDetection: &networkingv1.OutlierDetection{
+				Consecutive_5XxErrors: wrapperspb.UInt32(10),
+				MinHealthPercent:      50,
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, OutlierDetectionAction{}, map[string]any{
+		"consecutive5xxErrors": 1.0,
+		"interval":             1000.0,
+		"baseEjectionTime":     300000.0,
+		"maxEjectionPercent":   100.0,
+	})
+
+	// Start call
+	_, err := OutlierDetectionAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the outlier detection was replaced
+	dr := getDestinationRule(t, clientset)
+	require.True(t, proto.Equal(&networkingv1.OutlierDetection{
+		Consecutive_5XxErrors: wrapperspb.UInt32(1),
+		Interval:              durationpb.New(time.Second),
+		BaseEjectionTime:      durationpb.New(5 * time.Minute),
+		MaxEjectionPercent:    100,
+	}, dr.Spec.TrafficPolicy.OutlierDetection))
+
+	// Stop call
+	_, err = OutlierDetectionAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_loadBalancerLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			LoadBalancer: &networkingv1.LoadBalancerSettings{
+				LbPolicy: &networkingv1.LoadBalancerSettings_ConsistentHash{
+					ConsistentHash: &networkingv1.LoadBalancerSettings_ConsistentHashLB{
+						HashKey: &networkingv1.LoadBalancerSettings_ConsistentHashLB_HttpHeaderName{
+							HttpHeaderName: "x-user",
+						},
+					},
+				},
+				LocalityLbSetting: &networkingv1.LocalityLoadBalancerSetting{
+					Enabled: wrapperspb.Bool(true),
+				},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, LoadBalancerAction{}, map[string]any{
+		"policy": "RANDOM",
+	})
+
+	// Start call
+	_, err := LoadBalancerAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that only the policy was replaced
+	dr := getDestinationRule(t, clientset)
+	require.Equal(t, networkingv1.LoadBalancerSettings_RANDOM, dr.Spec.TrafficPolicy.LoadBalancer.GetSimple())
+	require.Nil(t, dr.Spec.TrafficPolicy.LoadBalancer.GetConsistentHash())
+	require.True(t, dr.Spec.TrafficPolicy.LoadBalancer.LocalityLbSetting.GetEnabled().GetValue())
+
+	// Stop call
+	_, err = LoadBalancerAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_toLoadBalancerTrafficPolicyRejectsUnknownPolicy(t *testing.T) {
+	_, err := toLoadBalancerTrafficPolicy(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{"policy": "UNSPECIFIED"},
+	})
+	require.Error(t, err)
+}
+
+func Test_clientTLSLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			Tls: &networkingv1.ClientTLSSettings{
+				Mode: networkingv1.ClientTLSSettings_ISTIO_MUTUAL,
+			},
+			PortLevelSettings: []*networkingv1.TrafficPolicy_PortTrafficPolicy{
+				{
+					Port: &networkingv1.PortSelector{Number: 9080},
+					Tls: &networkingv1.ClientTLSSettings{
+						Mode: networkingv1.ClientTLSSettings_ISTIO_MUTUAL,
+					},
+				},
+			},
+		},
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, ClientTLSAction{}, map[string]any{
+		"mode": "SIMPLE",
+		"sni":  "invalid.steadybit.local",
+	})
+
+	// Start call
+	result, err := ClientTLSAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	require.Equal(t, "Set TLS mode of DestinationRule reviews in namespace default to SIMPLE, affecting host reviews (subsets v1).", (*result.Messages)[0].Message)
+	state = extutil.JsonMangle(state)
+
+	// Check that the TLS settings were replaced, including the port level ones
+	expectedTls := &networkingv1.ClientTLSSettings{
+		Mode: networkingv1.ClientTLSSettings_SIMPLE,
+		Sni:  "invalid.steadybit.local",
+	}
+	dr := getDestinationRule(t, clientset)
+	require.True(t, proto.Equal(expectedTls, dr.Spec.TrafficPolicy.Tls))
+	require.True(t, proto.Equal(expectedTls, dr.Spec.TrafficPolicy.PortLevelSettings[0].Tls))
+
+	// Stop call
+	_, err = ClientTLSAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_localityFailoverLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			LoadBalancer: &networkingv1.LoadBalancerSettings{
+				LbPolicy: &networkingv1.LoadBalancerSettings_Simple{Simple: networkingv1.LoadBalancerSettings_LEAST_REQUEST},
+			},
+		},
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+				TrafficPolicy: &networkingv1.TrafficPolicy{
+					ConnectionPool: &networkingv1.ConnectionPoolSettings{
+						Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{MaxConnections: 10},
+					},
+				},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, LocalityFailoverAction{}, map[string]any{
+		"mode": "distribute",
+		"from": "*",
+		"to":   "us-east/zone1/*",
+	})
+
+	// Start call
+	_, err := LocalityFailoverAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the locality settings were replaced and outlier detection was added to the top-level only
+	expectedLocalityLbSetting := &networkingv1.LocalityLoadBalancerSetting{
+		Enabled: wrapperspb.Bool(true),
+		Distribute: []*networkingv1.LocalityLoadBalancerSetting_Distribute{
+			{From: "*", To: map[string]uint32{"us-east/zone1/*": 100}},
+		},
+	}
+	dr := getDestinationRule(t, clientset)
+	require.Equal(t, networkingv1.LoadBalancerSettings_LEAST_REQUEST, dr.Spec.TrafficPolicy.LoadBalancer.GetSimple())
+	require.True(t, proto.Equal(expectedLocalityLbSetting, dr.Spec.TrafficPolicy.LoadBalancer.LocalityLbSetting))
+	require.NotNil(t, dr.Spec.TrafficPolicy.OutlierDetection)
+	require.True(t, proto.Equal(expectedLocalityLbSetting, dr.Spec.Subsets[0].TrafficPolicy.LoadBalancer.LocalityLbSetting))
+	require.Nil(t, dr.Spec.Subsets[0].TrafficPolicy.OutlierDetection)
+
+	// Stop call
+	_, err = LocalityFailoverAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_toLocalityFailoverTrafficPolicyWithFailoverPriority(t *testing.T) {
+	trafficPolicy, err := toLocalityFailoverTrafficPolicy(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"mode":             "failoverPriority",
+			"failoverPriority": []any{"topology.istio.io/network", "topology.kubernetes.io/region"},
+		},
+	})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(&networkingv1.LocalityLoadBalancerSetting{
+		Enabled:          wrapperspb.Bool(true),
+		FailoverPriority: []string{"topology.istio.io/network", "topology.kubernetes.io/region"},
+	}, trafficPolicy.LoadBalancer.LocalityLbSetting))
+	require.NotNil(t, trafficPolicy.OutlierDetection)
+}
+
+func Test_toLocalityFailoverTrafficPolicyValidatesConfig(t *testing.T) {
+	for _, config := range []map[string]any{
+		{"mode": "distribute", "from": "*"},
+		{"mode": "distribute", "to": "us-east/zone1/*"},
+		{"mode": "failoverPriority", "failoverPriority": []any{}},
+		{"mode": "failover", "from": "us-west", "to": "us-east"},
+	} {
+		_, err := toLocalityFailoverTrafficPolicy(action_kit_api.PrepareActionRequestBody{Config: config})
+		require.Error(t, err, "config %v", config)
+	}
+}
+
+func Test_brokenSubsetLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+			},
+			{
+				Name:   "v2",
+				Labels: map[string]string{"version": "v2"},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, BrokenSubsetAction{}, map[string]any{
+		"subset": "v2",
+	})
+
+	// Start call
+	_, err := BrokenSubsetAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that only the selected subset was broken
+	dr := getDestinationRule(t, clientset)
+	require.Equal(t, map[string]string{"version": "v1"}, dr.Spec.Subsets[0].Labels)
+	require.Equal(t, map[string]string{"version": "v2", brokenSubsetLabel: "true"}, dr.Spec.Subsets[1].Labels)
+
+	// Stop call
+	_, err = BrokenSubsetAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_brokenSubsetFailsForUnknownSubset(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		Subsets: []*networkingv1.Subset{
+			{
+				Name:   "v1",
+				Labels: map[string]string{"version": "v1"},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+	state := prepareTestState(t, BrokenSubsetAction{}, map[string]any{
+		"subset": "v3",
+	})
+
+	// When
+	_, err := BrokenSubsetAction{}.Start(context.Background(), &state)
+
+	// Then
+	require.Error(t, err)
+	require.False(t, state.Applied)
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_connectionTimeoutsLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := &networkingv1.DestinationRule{
+		Host: "reviews",
+		TrafficPolicy: &networkingv1.TrafficPolicy{
+			ConnectionPool: &networkingv1.ConnectionPoolSettings{
+				Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+					MaxConnections: 100,
+					ConnectTimeout: durationpb.New(5 * time.Second),
+				},
+			},
+		},
+	}
+	createDestinationRule(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, ConnectionTimeoutsAction{}, map[string]any{
+		"connectTimeout":           10.0,
+		"tcpIdleTimeout":           1000.0,
+		"httpIdleTimeout":          2000.0,
+		"maxRequestsPerConnection": 1.0,
+	})
+
+	// Start call
+	_, err := ConnectionTimeoutsAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the timeouts were applied, keeping the other connection pool settings
+	dr := getDestinationRule(t, clientset)
+	require.True(t, proto.Equal(&networkingv1.ConnectionPoolSettings{
+		Tcp: &networkingv1.ConnectionPoolSettings_TCPSettings{
+			MaxConnections: 100,
+			ConnectTimeout: durationpb.New(10 * time.Millisecond),
+			IdleTimeout:    durationpb.New(time.Second),
+		},
+		Http: &networkingv1.ConnectionPoolSettings_HTTPSettings{
+			IdleTimeout:              durationpb.New(2 * time.Second),
+			MaxRequestsPerConnection: 1,
+		},
+	}, dr.Spec.TrafficPolicy.ConnectionPool))
+
+	// Stop call
+	_, err = ConnectionTimeoutsAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original settings were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
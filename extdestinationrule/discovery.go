@@ -0,0 +1,235 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-kit/extbuild"
+	networkingv1 "istio.io/api/networking/v1"
+	"maps"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const discoveryBasePath = basePath + "/discovery"
+
+type destinationRuleDiscovery struct {
+}
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*destinationRuleDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*destinationRuleDiscovery)(nil)
+)
+
+func NewDestinationRuleDiscovery() discovery_kit_sdk.TargetDiscovery {
+	discovery := &destinationRuleDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 30*time.Second),
+	)
+}
+
+func (d *destinationRuleDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: DestinationRuleTargetID,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			Method:       "GET",
+			Path:         discoveryBasePath + "/discovered-targets",
+			CallInterval: new("30s"),
+		},
+	}
+}
+
+func (d *destinationRuleDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       DestinationRuleTargetID,
+		Icon:     new(targetIcon),
+		Label:    discovery_kit_api.PluralLabel{One: "Destination Rule", Other: "Destination Rules"},
+		Category: new("Kubernetes"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "istio.destination-rule.name"},
+				{Attribute: "istio.destination-rule.host"},
+				{Attribute: "k8s.namespace"},
+				{Attribute: "k8s.cluster-name"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "istio.destination-rule.name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *destinationRuleDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "istio.destination-rule.name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination Rule",
+				Other: "Destination Rules",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.host",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination Rule host",
+				Other: "Destination Rule hosts",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.subset",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination Rule subset",
+				Other: "Destination Rule subsets",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.outlier-detection",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Outlier detection enabled",
+				Other: "Outlier detection enabled",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.tls-mode",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "TLS mode",
+				Other: "TLS modes",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.load-balancer",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Load balancer policy",
+				Other: "Load balancer policies",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.virtual-service",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Virtual Service",
+				Other: "Virtual Services",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.locality-lb.enabled",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Locality load balancing enabled",
+				Other: "Locality load balancing enabled",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.locality-lb.distribute",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Locality distribution",
+				Other: "Locality distributions",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.locality-lb.failover",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Locality failover",
+				Other: "Locality failovers",
+			},
+		},
+		{
+			Attribute: "istio.destination-rule.locality-lb.failover-priority",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Locality failover priority",
+				Other: "Locality failover priorities",
+			},
+		},
+	}
+}
+
+func (d *destinationRuleDiscovery) DiscoverTargets(_ context.Context) ([]discovery_kit_api.Target, error) {
+	return getDestinationRuleTargets(extclient.Istio), nil
+}
+
+func getDestinationRuleTargets(client *extclient.IstioClient) []discovery_kit_api.Target {
+	destinationRules := client.GetDestinationRules()
+	result := make([]discovery_kit_api.Target, len(destinationRules))
+
+	for i, destinationRule := range destinationRules {
+		attributes := make(map[string][]string)
+		attributes["istio.destination-rule.name"] = []string{destinationRule.Name}
+		attributes["istio.destination-rule.host"] = []string{extclient.ToFullyQualifiedHost(destinationRule.Namespace, destinationRule.Spec.Host)}
+		attributes["k8s.namespace"] = []string{destinationRule.Namespace}
+		attributes["k8s.cluster-name"] = []string{extconfig.Config.ClusterName}
+
+		for _, subset := range destinationRule.Spec.Subsets {
+			attributes["istio.destination-rule.subset"] = append(attributes["istio.destination-rule.subset"], subset.Name)
+		}
+
+		trafficPolicy := destinationRule.Spec.TrafficPolicy
+		attributes["istio.destination-rule.outlier-detection"] = []string{strconv.FormatBool(trafficPolicy.GetOutlierDetection() != nil)}
+		if tls := trafficPolicy.GetTls(); tls != nil {
+			attributes["istio.destination-rule.tls-mode"] = []string{tls.Mode.String()}
+		}
+		if loadBalancer := getLoadBalancerPolicy(trafficPolicy.GetLoadBalancer()); loadBalancer != "" {
+			attributes["istio.destination-rule.load-balancer"] = []string{loadBalancer}
+		}
+		addLocalityLbSettingAttributes(attributes, trafficPolicy.GetLoadBalancer().GetLocalityLbSetting())
+
+		for _, virtualService := range client.GetVirtualServicesForDestinationRule(destinationRule) {
+			attributes["istio.destination-rule.virtual-service"] = append(attributes["istio.destination-rule.virtual-service"], virtualService.Namespace+"/"+virtualService.Name)
+		}
+
+		for key, value := range destinationRule.Labels {
+			attributes["k8s.destination-rule.label."+key] = []string{value}
+		}
+
+		result[i] = discovery_kit_api.Target{
+			Id:         fmt.Sprintf("%s/%s/%s", extconfig.Config.ClusterName, destinationRule.Namespace, destinationRule.Name),
+			Label:      destinationRule.Name,
+			TargetType: DestinationRuleTargetID,
+			Attributes: attributes,
+		}
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(result, extconfig.Config.DiscoveryAttributesExcludesDestinationRule)
+}
+
+func getLoadBalancerPolicy(loadBalancer *networkingv1.LoadBalancerSettings) string {
+	switch {
+	case loadBalancer.GetSimple() != networkingv1.LoadBalancerSettings_UNSPECIFIED:
+		return loadBalancer.GetSimple().String()
+	case loadBalancer.GetConsistentHash() != nil:
+		return "CONSISTENT_HASH"
+	default:
+		return ""
+	}
+}
+
+func addLocalityLbSettingAttributes(attributes map[string][]string, localityLbSetting *networkingv1.LocalityLoadBalancerSetting) {
+	if localityLbSetting == nil {
+		return
+	}
+
+	// Locality load balancing is enabled unless explicitly disabled
+	attributes["istio.destination-rule.locality-lb.enabled"] = []string{strconv.FormatBool(localityLbSetting.GetEnabled() == nil || localityLbSetting.GetEnabled().GetValue())}
+	for _, distribute := range localityLbSetting.Distribute {
+		for _, to := range slices.Sorted(maps.Keys(distribute.To)) {
+			attributes["istio.destination-rule.locality-lb.distribute"] = append(attributes["istio.destination-rule.locality-lb.distribute"], fmt.Sprintf("%s->%s=%d", distribute.From, to, distribute.To[to]))
+		}
+	}
+	for _, failover := range localityLbSetting.Failover {
+		attributes["istio.destination-rule.locality-lb.failover"] = append(attributes["istio.destination-rule.locality-lb.failover"], fmt.Sprintf("%s->%s", failover.From, failover.To))
+	}
+	if len(localityLbSetting.FailoverPriority) > 0 {
+		attributes["istio.destination-rule.locality-lb.failover-priority"] = slices.Clone(localityLbSetting.FailoverPriority)
+	}
+}
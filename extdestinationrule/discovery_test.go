@@ -0,0 +1,145 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extdestinationrule
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	testclient "istio.io/client-go/pkg/clientset/versioned/fake"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/features"
+	clientfeaturestesting "k8s.io/client-go/features/testing"
+	k8stestclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func Test_getDiscoveredDestinationRules(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extconfig.Config.ClusterName = "development"
+	extconfig.Config.DiscoveryAttributesExcludesDestinationRule = []string{"k8s.destination-rule.label.toIgnore"}
+
+	_, err := clientset.
+		NetworkingV1().
+		DestinationRules("default").
+		Create(context.Background(), &apiv1.DestinationRule{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "reviews",
+				Namespace: "default",
+				Labels: map[string]string{
+					"best-city": "Kevelaer",
+					"toIgnore":  "Bielefeld",
+				},
+			},
+			Spec: networkingv1.DestinationRule{
+				Host: "reviews.default.svc.cluster.local",
+				TrafficPolicy: &networkingv1.TrafficPolicy{
+					LoadBalancer: &networkingv1.LoadBalancerSettings{
+						LbPolicy: &networkingv1.LoadBalancerSettings_Simple{Simple: networkingv1.LoadBalancerSettings_LEAST_REQUEST},
+						LocalityLbSetting: &networkingv1.LocalityLoadBalancerSetting{
+							Distribute: []*networkingv1.LocalityLoadBalancerSetting_Distribute{
+								{
+									From: "us-west/zone1/*",
+									To:   map[string]uint32{"us-west/zone1/*": 80, "us-west/zone2/*": 20},
+								},
+							},
+							Failover: []*networkingv1.LocalityLoadBalancerSetting_Failover{
+								{From: "us-west", To: "us-east"},
+							},
+						},
+					},
+					Tls: &networkingv1.ClientTLSSettings{
+						Mode: networkingv1.ClientTLSSettings_ISTIO_MUTUAL,
+					},
+				},
+				Subsets: []*networkingv1.Subset{
+					{Name: "v1", Labels: map[string]string{"version": "v1"}},
+					{Name: "v2", Labels: map[string]string{"version": "v2"}},
+				},
+			},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(getDestinationRuleTargets(client)) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then
+	targets := getDestinationRuleTargets(client)
+	require.Len(t, targets, 1)
+	target := targets[0]
+	require.Equal(t, "development/default/reviews", target.Id)
+	require.Equal(t, DestinationRuleTargetID, target.TargetType)
+	require.Equal(t, "reviews", target.Label)
+	require.Equal(t, map[string][]string{
+		"istio.destination-rule.name":                   {"reviews"},
+		"istio.destination-rule.host":                   {"reviews.default.svc.cluster.local"},
+		"istio.destination-rule.subset":                 {"v1", "v2"},
+		"istio.destination-rule.outlier-detection":      {"false"},
+		"istio.destination-rule.tls-mode":               {"ISTIO_MUTUAL"},
+		"istio.destination-rule.load-balancer":          {"LEAST_REQUEST"},
+		"istio.destination-rule.locality-lb.enabled":    {"true"},
+		"istio.destination-rule.locality-lb.distribute": {"us-west/zone1/*->us-west/zone1/*=80", "us-west/zone1/*->us-west/zone2/*=20"},
+		"istio.destination-rule.locality-lb.failover":   {"us-west->us-east"},
+		"k8s.namespace":                                 {"default"},
+		"k8s.cluster-name":                              {"development"},
+		"k8s.destination-rule.label.best-city":          {"Kevelaer"},
+	}, target.Attributes)
+}
+
+func Test_getDiscoveredDestinationRulesLinksVirtualServices(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extconfig.Config.ClusterName = "development"
+
+	_, err := clientset.NetworkingV1().DestinationRules("default").Create(context.Background(), &apiv1.DestinationRule{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "default"},
+		Spec:       networkingv1.DestinationRule{Host: "reviews"},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	for _, namespace := range []string{"default", "shop"} {
+		_, err = clientset.NetworkingV1().VirtualServices(namespace).Create(context.Background(), &apiv1.VirtualService{
+			ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: namespace},
+			Spec: networkingv1.VirtualService{
+				Hosts: []string{"reviews.default.svc.cluster.local"},
+				Http: []*networkingv1.HTTPRoute{
+					{Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "reviews"}}}},
+				},
+			},
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(getDestinationRuleTargets(client)) == 1 && len(client.GetVirtualServices()) == 2
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then the VirtualService in namespace shop routes to reviews.shop, which the DestinationRule doesn't apply to
+	attributes := getDestinationRuleTargets(client)[0].Attributes
+	require.Equal(t, []string{"default/reviews"}, attributes["istio.destination-rule.virtual-service"])
+	require.Equal(t, []string{"reviews.default.svc.cluster.local"}, attributes["istio.destination-rule.host"])
+}
+
+func getTestClient(t testing.TB, stopCh <-chan struct{}) (*extclient.IstioClient, versionedClient.Interface) {
+	// Disable WatchListClient feature gate: the fake client doesn't support
+	// the bookmark events required by the WatchList stream, causing informers
+	// to hang indefinitely.
+	clientfeaturestesting.SetFeatureDuringTest(t, features.WatchListClient, false)
+	clientset := testclient.NewSimpleClientset()
+	client := extclient.NewIstioClient(clientset, k8stestclient.NewSimpleClientset(), stopCh)
+	return client, clientset
+}
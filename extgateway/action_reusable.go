@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	"slices"
+	"strconv"
+)
+
+type ActionState struct {
+	ExecutionId string
+	Namespace   string
+	Name        string
+	// Port and Host select the servers of the Gateway modified by the attack, empty values match all servers.
+	Port uint32
+	Host string
+	// CredentialName is the secret the TLS settings of the selected servers are pointed to.
+	CredentialName string
+	// Applied tells whether the attack modified the Gateway, i.e. whether the original servers must be restored.
+	Applied         bool
+	OriginalServers []*networkingv1.Server
+}
+
+func getDurationParameter(description string) action_kit_api.ActionParameter {
+	return action_kit_api.ActionParameter{
+		Name:         "duration",
+		Label:        "Duration",
+		Description:  new(description),
+		Type:         action_kit_api.ActionParameterTypeDuration,
+		DefaultValue: new("30s"),
+		Required:     new(true),
+		Order:        new(0),
+	}
+}
+
+func getServerSelectionParameters() []action_kit_api.ActionParameter {
+	return []action_kit_api.ActionParameter{
+		{
+			Name:        "port",
+			Label:       "Port",
+			Description: new("Port of the servers to attack. At least one of port and host is required."),
+			Type:        action_kit_api.ActionParameterTypeString,
+			Options: new([]action_kit_api.ParameterOption{
+				action_kit_api.ParameterOptionsFromTargetAttribute{
+					Attribute: "istio.gateway.port",
+				},
+			}),
+			Required: new(false),
+			Order:    new(1),
+		},
+		{
+			Name:        "host",
+			Label:       "Host",
+			Description: new("Host of the servers to attack. At least one of port and host is required."),
+			Type:        action_kit_api.ActionParameterTypeString,
+			Options: new([]action_kit_api.ParameterOption{
+				action_kit_api.ParameterOptionsFromTargetAttribute{
+					Attribute: "istio.gateway.host",
+				},
+			}),
+			Required: new(false),
+			Order:    new(2),
+		},
+	}
+}
+
+func getTargetSelection() *action_kit_api.TargetSelection {
+	return new(action_kit_api.TargetSelection{
+		TargetType: GatewayTargetID,
+		SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+			{
+				Label: "name",
+				Query: "istio.gateway.name=\"\"",
+			},
+		}),
+	})
+}
+
+func prepareGatewayChange(state *ActionState, request action_kit_api.PrepareActionRequestBody) error {
+	state.ExecutionId = request.ExecutionId.String()
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["istio.gateway.name"][0]
+	state.Host = extutil.ToString(request.Config["host"])
+
+	if port := extutil.ToString(request.Config["port"]); port != "" {
+		number, err := strconv.ParseUint(port, 10, 32)
+		if err != nil {
+			return extension_kit.ToError("Failed prepare attack", fmt.Errorf("invalid port '%s'", port))
+		}
+		state.Port = uint32(number)
+	}
+
+	if state.Port == 0 && state.Host == "" {
+		return extension_kit.ToError("Failed prepare attack", errors.New("at least one of port and host is required"))
+	}
+	return nil
+}
+
+// startGatewayChange snapshots the servers of the Gateway into the state before applying the change, so that they can
+// be restored exactly on stop. The Gateway is marked as modified, to refuse overlapping attacks on it.
+func startGatewayChange(ctx context.Context, state *ActionState, apply func(state *ActionState, spec *networkingv1.Gateway) error) error {
+	err := extclient.Istio.UpdateGateway(ctx, state.Namespace, state.Name, func(gw *apinetworkingv1.Gateway) error {
+		if err := extclient.MarkModified(gw, state.ExecutionId); err != nil {
+			return err
+		}
+		state.OriginalServers = cloneServers(gw.Spec.Servers)
+		if !slices.ContainsFunc(gw.Spec.Servers, state.matches) {
+			return fmt.Errorf("no server matches %s", state.describeSelection())
+		}
+		return apply(state, &gw.Spec)
+	})
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to modify Gateway %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = true
+	return nil
+}
+
+func stopGatewayChange(ctx context.Context, state *ActionState) error {
+	if !state.Applied {
+		return nil
+	}
+
+	err := extclient.Istio.UpdateGateway(ctx, state.Namespace, state.Name, func(gw *apinetworkingv1.Gateway) error {
+		gw.Spec.Servers = cloneServers(state.OriginalServers)
+		extclient.UnmarkModified(gw, state.ExecutionId)
+		return nil
+	})
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to restore Gateway %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = false
+	return nil
+}
+
+// matches tells whether the server is selected by the port and host of the attack.
+func (state *ActionState) matches(server *networkingv1.Server) bool {
+	return (state.Port == 0 || server.GetPort().GetNumber() == state.Port) &&
+		(state.Host == "" || slices.Contains(server.Hosts, state.Host))
+}
+
+func (state *ActionState) describeSelection() string {
+	switch {
+	case state.Port == 0:
+		return fmt.Sprintf("host %s", state.Host)
+	case state.Host == "":
+		return fmt.Sprintf("port %d", state.Port)
+	default:
+		return fmt.Sprintf("port %d and host %s", state.Port, state.Host)
+	}
+}
+
+func cloneServers(servers []*networkingv1.Server) []*networkingv1.Server {
+	if servers == nil {
+		return nil
+	}
+	result := make([]*networkingv1.Server, len(servers))
+	for i, server := range servers {
+		result[i] = server.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,331 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_serverDisableLifecycle(t *testing.T) {
+	tests := []struct {
+		name            string
+		config          map[string]any
+		expectedServers []*networkingv1.Server
+	}{
+		{
+			name:   "port",
+			config: map[string]any{"port": "443"},
+			expectedServers: []*networkingv1.Server{
+				{Name: "http", Port: &networkingv1.Port{Number: 80, Name: "http", Protocol: "HTTP"}, Hosts: []string{"shop.example.com", "api.example.com"}},
+			},
+		},
+		{
+			name:   "host",
+			config: map[string]any{"host": "api.example.com"},
+			expectedServers: []*networkingv1.Server{
+				{Name: "http", Port: &networkingv1.Port{Number: 80, Name: "http", Protocol: "HTTP"}, Hosts: []string{"shop.example.com"}},
+				{Name: "https", Port: &networkingv1.Port{Number: 443, Name: "https", Protocol: "HTTPS"}, Hosts: []string{"shop.example.com"}, Tls: &networkingv1.ServerTLSSettings{Mode: networkingv1.ServerTLSSettings_SIMPLE, CredentialName: "shop-cert"}},
+			},
+		},
+		{
+			name:   "port and host",
+			config: map[string]any{"port": "80", "host": "shop.example.com"},
+			expectedServers: []*networkingv1.Server{
+				{Name: "http", Port: &networkingv1.Port{Number: 80, Name: "http", Protocol: "HTTP"}, Hosts: []string{"api.example.com"}},
+				{Name: "https", Port: &networkingv1.Port{Number: 443, Name: "https", Protocol: "HTTPS"}, Hosts: []string{"shop.example.com", "api.example.com"}, Tls: &networkingv1.ServerTLSSettings{Mode: networkingv1.ServerTLSSettings_SIMPLE, CredentialName: "shop-cert"}},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh)
+			extclient.Istio = client
+
+			originalSpec := getTestGatewaySpec()
+			createGateway(t, clientset, originalSpec)
+
+			// Prepare call
+			state := prepareTestState(t, ServerDisableAction{}, tt.config)
+
+			// Start call
+			_, err := ServerDisableAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			state = extutil.JsonMangle(state)
+
+			// Check that the servers were modified
+			gw := getGateway(t, clientset)
+			require.True(t, proto.Equal(&networkingv1.Gateway{Selector: originalSpec.Selector, Servers: tt.expectedServers}, &gw.Spec), "got %v", &gw.Spec)
+
+			// Stop call
+			_, err = ServerDisableAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that the exact original servers were restored
+			requireSpecRestored(t, clientset, originalSpec)
+		})
+	}
+}
+
+func Test_serverDisableFails(t *testing.T) {
+	tests := []struct {
+		name   string
+		config map[string]any
+	}{
+		{name: "no matching server", config: map[string]any{"port": "8443"}},
+		{name: "all servers removed", config: map[string]any{"host": "shop.example.com"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// Given
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh)
+			extclient.Istio = client
+			originalSpec := getTestGatewaySpec()
+			originalSpec.Servers[0].Hosts = []string{"shop.example.com"}
+			originalSpec.Servers[1].Hosts = []string{"shop.example.com"}
+			createGateway(t, clientset, originalSpec)
+			state := prepareTestState(t, ServerDisableAction{}, tt.config)
+
+			// When
+			_, err := ServerDisableAction{}.Start(context.Background(), &state)
+
+			// Then
+			require.Error(t, err)
+			require.False(t, state.Applied)
+			requireSpecRestored(t, clientset, originalSpec)
+		})
+	}
+}
+
+func Test_overlappingAttacksAreRefused(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+	originalSpec := getTestGatewaySpec()
+	createGateway(t, clientset, originalSpec)
+
+	// Start the first attack
+	state := prepareTestState(t, ServerDisableAction{}, map[string]any{"port": "443"})
+	_, err := ServerDisableAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that a second attack on the same Gateway is refused
+	otherState := prepareTestState(t, ServerDisableAction{}, map[string]any{"port": "80"})
+	otherState.ExecutionId = "e5b4a0a4-2b8f-4b59-8f7e-1a8f4c3d9a11"
+	_, err = ServerDisableAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+
+	// Stop the first attack
+	_, err = ServerDisableAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the original servers were restored and the mark removed
+	requireSpecRestored(t, clientset, originalSpec)
+	require.Empty(t, getGateway(t, clientset).Annotations)
+}
+
+func Test_prepareGatewayChangeRequiresPortOrHost(t *testing.T) {
+	state := ServerDisableAction{}.NewEmptyState()
+	_, err := ServerDisableAction{}.Prepare(context.Background(), &state, action_kit_api.PrepareActionRequestBody{
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":      {"istio-system"},
+				"istio.gateway.name": {"shop-gateway"},
+			},
+		},
+		Config: map[string]any{},
+	})
+	require.Error(t, err)
+}
+
+func getTestGatewaySpec() *networkingv1.Gateway {
+	return &networkingv1.Gateway{
+		Selector: map[string]string{"istio": "ingressgateway"},
+		Servers: []*networkingv1.Server{
+			{
+				Name:  "http",
+				Port:  &networkingv1.Port{Number: 80, Name: "http", Protocol: "HTTP"},
+				Hosts: []string{"shop.example.com", "api.example.com"},
+			},
+			{
+				Name:  "https",
+				Port:  &networkingv1.Port{Number: 443, Name: "https", Protocol: "HTTPS"},
+				Hosts: []string{"shop.example.com", "api.example.com"},
+				Tls: &networkingv1.ServerTLSSettings{
+					Mode:           networkingv1.ServerTLSSettings_SIMPLE,
+					CredentialName: "shop-cert",
+				},
+			},
+		},
+	}
+}
+
+func createGateway(t *testing.T, clientset versionedClient.Interface, spec *networkingv1.Gateway) {
+	_, err := clientset.
+		NetworkingV1().
+		Gateways("istio-system").
+		Create(context.Background(), &apinetworkingv1.Gateway{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop-gateway",
+				Namespace: "istio-system",
+			},
+			Spec: *spec.DeepCopy(),
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func getGateway(t *testing.T, clientset versionedClient.Interface) *apinetworkingv1.Gateway {
+	gw, err := clientset.
+		NetworkingV1().
+		Gateways("istio-system").
+		Get(context.Background(), "shop-gateway", v1.GetOptions{})
+	require.NoError(t, err)
+	return gw
+}
+
+func requireSpecRestored(t *testing.T, clientset versionedClient.Interface, originalSpec *networkingv1.Gateway) {
+	gw := getGateway(t, clientset)
+	require.True(t, proto.Equal(originalSpec, &gw.Spec), "expected %v, got %v", originalSpec, &gw.Spec)
+}
+
+// prepareTestState runs the prepare call of the action and sends the state through JSON, like the action SDK does.
+func prepareTestState(t *testing.T, action action_kit_sdk.Action[ActionState], config map[string]any) ActionState {
+	state := action.NewEmptyState()
+	_, err := action.Prepare(context.Background(), &state, extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":      {"istio-system"},
+				"istio.gateway.name": {"shop-gateway"},
+			},
+		},
+		Config: config,
+	}))
+	require.NoError(t, err)
+	return extutil.JsonMangle(state)
+}
+
+func Test_tlsCredentialLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	originalSpec := getTestGatewaySpec()
+	originalSpec.Servers = append(originalSpec.Servers, &networkingv1.Server{
+		Name:  "tls-passthrough",
+		Port:  &networkingv1.Port{Number: 443, Name: "tls", Protocol: "TLS"},
+		Hosts: []string{"legacy.example.com"},
+		Tls:   &networkingv1.ServerTLSSettings{Mode: networkingv1.ServerTLSSettings_PASSTHROUGH},
+	})
+	originalSpec.Servers[1].Tls = &networkingv1.ServerTLSSettings{
+		Mode:              networkingv1.ServerTLSSettings_MUTUAL,
+		ServerCertificate: "/etc/certs/tls.crt",
+		PrivateKey:        "/etc/certs/tls.key",
+		CaCertificates:    "/etc/certs/ca.crt",
+	}
+	createGateway(t, clientset, originalSpec)
+
+	// Prepare call
+	state := prepareTestState(t, TLSCredentialAction{}, map[string]any{
+		"port":           "443",
+		"credentialName": "expired-cert",
+	})
+
+	// Start call
+	_, err := TLSCredentialAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that only the server terminating TLS was changed
+	gw := getGateway(t, clientset)
+	require.True(t, proto.Equal(&networkingv1.ServerTLSSettings{
+		Mode:           networkingv1.ServerTLSSettings_MUTUAL,
+		CredentialName: "expired-cert",
+	}, gw.Spec.Servers[1].Tls), "got %v", gw.Spec.Servers[1].Tls)
+	require.True(t, proto.Equal(originalSpec.Servers[2], gw.Spec.Servers[2]))
+
+	// Stop call
+	_, err = TLSCredentialAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original servers were restored
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_tlsCredentialFailsWithoutTLSServer(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+	originalSpec := getTestGatewaySpec()
+	createGateway(t, clientset, originalSpec)
+	state := prepareTestState(t, TLSCredentialAction{}, map[string]any{
+		"port":           "80",
+		"credentialName": "expired-cert",
+	})
+
+	// When
+	_, err := TLSCredentialAction{}.Start(context.Background(), &state)
+
+	// Then
+	require.Error(t, err)
+	require.False(t, state.Applied)
+	requireSpecRestored(t, clientset, originalSpec)
+}
+
+func Test_tlsCredentialRefusedDuringServerDisable(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+	originalSpec := getTestGatewaySpec()
+	createGateway(t, clientset, originalSpec)
+
+	// Start a server disable attack on the plain HTTP server
+	state := prepareTestState(t, ServerDisableAction{}, map[string]any{"port": "80"})
+	_, err := ServerDisableAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that breaking the certificate of the same Gateway is refused
+	otherState := prepareTestState(t, TLSCredentialAction{}, map[string]any{
+		"port":           "443",
+		"credentialName": "expired-cert",
+	})
+	otherState.ExecutionId = "e5b4a0a4-2b8f-4b59-8f7e-1a8f4c3d9a11"
+	_, err = TLSCredentialAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+	require.False(t, otherState.Applied)
+
+	// Stop the server disable attack
+	_, err = ServerDisableAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the original servers were restored and the mark removed
+	requireSpecRestored(t, clientset, originalSpec)
+	require.Empty(t, getGateway(t, clientset).Annotations)
+}
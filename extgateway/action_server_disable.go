@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	networkingv1 "istio.io/api/networking/v1"
+	"slices"
+)
+
+type ServerDisableAction struct {
+}
+
+func NewServerDisableAction() action_kit_sdk.Action[ActionState] {
+	return ServerDisableAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*ServerDisableAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*ServerDisableAction)(nil)
+
+func (f ServerDisableAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f ServerDisableAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.server-disable", GatewayTargetID),
+		Label:           "Disable Gateway Server",
+		Description:     "Removes a host or port from the servers of the targeted gateways, so that it is no longer served by the ingress, simulating a broken ingress listener after a bad upgrade.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: append([]action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the host or port should not be served."),
+		}, getServerSelectionParameters()...),
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f ServerDisableAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareGatewayChange(state, request)
+}
+
+func (f ServerDisableAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startGatewayChange(ctx, state, disableServers)
+}
+
+func (f ServerDisableAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopGatewayChange(ctx, state)
+}
+
+// disableServers removes the selected host from the matching servers, or the matching servers as a whole when no host
+// is selected. Servers left without hosts are removed, too.
+func disableServers(state *ActionState, spec *networkingv1.Gateway) error {
+	servers := make([]*networkingv1.Server, 0, len(spec.Servers))
+	for _, server := range spec.Servers {
+		if !state.matches(server) {
+			servers = append(servers, server)
+			continue
+		}
+		if state.Host == "" {
+			continue
+		}
+
+		server.Hosts = slices.DeleteFunc(server.Hosts, func(host string) bool {
+			return host == state.Host
+		})
+		if len(server.Hosts) > 0 {
+			servers = append(servers, server)
+		}
+	}
+
+	if len(servers) == 0 {
+		return errors.New("removing all servers is not possible, Istio rejects gateways without servers")
+	}
+	spec.Servers = servers
+	return nil
+}
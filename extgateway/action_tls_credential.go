@@ -0,0 +1,107 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+)
+
+type TLSCredentialAction struct {
+}
+
+func NewTLSCredentialAction() action_kit_sdk.Action[ActionState] {
+	return TLSCredentialAction{}
+}
+
+var _ action_kit_sdk.Action[ActionState] = (*TLSCredentialAction)(nil)
+var _ action_kit_sdk.ActionWithStop[ActionState] = (*TLSCredentialAction)(nil)
+
+func (f TLSCredentialAction) NewEmptyState() ActionState {
+	return ActionState{}
+}
+
+func (f TLSCredentialAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.tls-credential", GatewayTargetID),
+		Label:           "Break Gateway TLS Certificate",
+		Description:     "Points the TLS settings of servers of the targeted gateways to a non-existent or expired certificate secret, causing TLS handshake failures at the edge like a failed certificate rotation.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: append(append([]action_kit_api.ActionParameter{
+			getDurationParameter("Duration defining for how long the TLS certificate should be broken."),
+		}, getServerSelectionParameters()...), action_kit_api.ActionParameter{
+			Name:         "credentialName",
+			Label:        "Credential name",
+			Description:  new("Name of the secret used as TLS credential instead. Use a non-existent secret, or one holding an expired certificate."),
+			Type:         action_kit_api.ActionParameterTypeString,
+			DefaultValue: new("steadybit-nonexistent-credential"),
+			Required:     new(true),
+			Order:        new(3),
+		}),
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f TLSCredentialAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.CredentialName = extutil.ToString(request.Config["credentialName"])
+	if state.CredentialName == "" {
+		return nil, extension_kit.ToError("Failed prepare attack", errors.New("credential name is required"))
+	}
+	return nil, prepareGatewayChange(state, request)
+}
+
+func (f TLSCredentialAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
+	return nil, startGatewayChange(ctx, state, replaceTLSCredential)
+}
+
+func (f TLSCredentialAction) Stop(ctx context.Context, state *ActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopGatewayChange(ctx, state)
+}
+
+// replaceTLSCredential points all matching servers terminating TLS to the credential of the attack. Other certificate
+// sources are dropped, as Istio allows only one of them. The original TLS settings are restored from the snapshot.
+func replaceTLSCredential(state *ActionState, spec *networkingv1.Gateway) error {
+	replaced := false
+	for _, server := range spec.Servers {
+		if !state.matches(server) || !terminatesTLS(server.Tls) {
+			continue
+		}
+
+		server.Tls.CredentialName = state.CredentialName
+		server.Tls.CredentialNames = nil
+		server.Tls.TlsCertificates = nil
+		server.Tls.ServerCertificate = ""
+		server.Tls.PrivateKey = ""
+		server.Tls.CaCertificates = ""
+		replaced = true
+	}
+
+	if !replaced {
+		return fmt.Errorf("no server terminating TLS matches %s", state.describeSelection())
+	}
+	return nil
+}
+
+func terminatesTLS(tls *networkingv1.ServerTLSSettings) bool {
+	switch tls.GetMode() {
+	case networkingv1.ServerTLSSettings_SIMPLE, networkingv1.ServerTLSSettings_MUTUAL, networkingv1.ServerTLSSettings_OPTIONAL_MUTUAL:
+		return true
+	default:
+		return false
+	}
+}
@@ -0,0 +1,10 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+const (
+	GatewayTargetID = "com.steadybit.extension_istio.gateway"
+	targetIcon      = "data:image/svg+xml,%3Csvg%20xmlns%3D%22http%3A%2F%2Fwww.w3.org%2F2000%2Fsvg%22%20width%3D%2264%22%20height%3D%2264%22%3E%3Cpath%20d%3D%22M11.3%20420.2h314.8l-196.7%2059zm0-19.7l118.1-19.7V164.4zM149%20380.8l177.1%2019.7L149%207z%22%20transform%3D%22matrix(.135536%200%200%20.135536%209.135112%20-.948751)%22%20fill%3D%22currentColor%22%2F%3E%3C%2Fsvg%3E"
+	basePath        = "/gateway"
+)
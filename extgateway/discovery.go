@@ -0,0 +1,183 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-kit/extbuild"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const discoveryBasePath = basePath + "/discovery"
+
+type gatewayDiscovery struct {
+}
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*gatewayDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*gatewayDiscovery)(nil)
+)
+
+func NewGatewayDiscovery() discovery_kit_sdk.TargetDiscovery {
+	discovery := &gatewayDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 30*time.Second),
+	)
+}
+
+func (d *gatewayDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: GatewayTargetID,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			Method:       "GET",
+			Path:         discoveryBasePath + "/discovered-targets",
+			CallInterval: new("30s"),
+		},
+	}
+}
+
+func (d *gatewayDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       GatewayTargetID,
+		Icon:     new(targetIcon),
+		Label:    discovery_kit_api.PluralLabel{One: "Gateway", Other: "Gateways"},
+		Category: new("Kubernetes"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "istio.gateway.name"},
+				{Attribute: "istio.gateway.host"},
+				{Attribute: "k8s.namespace"},
+				{Attribute: "k8s.cluster-name"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "istio.gateway.name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *gatewayDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "istio.gateway.name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Gateway",
+				Other: "Gateways",
+			},
+		},
+		{
+			Attribute: "istio.gateway.server",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Gateway server",
+				Other: "Gateway servers",
+			},
+		},
+		{
+			Attribute: "istio.gateway.port",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Gateway port",
+				Other: "Gateway ports",
+			},
+		},
+		{
+			Attribute: "istio.gateway.protocol",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Gateway protocol",
+				Other: "Gateway protocols",
+			},
+		},
+		{
+			Attribute: "istio.gateway.host",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Gateway host",
+				Other: "Gateway hosts",
+			},
+		},
+		{
+			Attribute: "istio.gateway.tls-mode",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "TLS mode",
+				Other: "TLS modes",
+			},
+		},
+		{
+			Attribute: "istio.gateway.credential-name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "TLS credential name",
+				Other: "TLS credential names",
+			},
+		},
+	}
+}
+
+func (d *gatewayDiscovery) DiscoverTargets(_ context.Context) ([]discovery_kit_api.Target, error) {
+	return getGatewayTargets(extclient.Istio), nil
+}
+
+func getGatewayTargets(client *extclient.IstioClient) []discovery_kit_api.Target {
+	gateways := client.GetGateways()
+	result := make([]discovery_kit_api.Target, len(gateways))
+
+	for i, gateway := range gateways {
+		attributes := make(map[string][]string)
+		attributes["istio.gateway.name"] = []string{gateway.Name}
+		attributes["k8s.namespace"] = []string{gateway.Namespace}
+		attributes["k8s.cluster-name"] = []string{extconfig.Config.ClusterName}
+
+		for _, server := range gateway.Spec.Servers {
+			if server.Name != "" {
+				attributes["istio.gateway.server"] = extclient.AppendDistinct(attributes["istio.gateway.server"], server.Name)
+			}
+			if port := server.GetPort(); port != nil {
+				attributes["istio.gateway.port"] = extclient.AppendDistinct(attributes["istio.gateway.port"], strconv.FormatUint(uint64(port.Number), 10))
+				attributes["istio.gateway.protocol"] = extclient.AppendDistinct(attributes["istio.gateway.protocol"], port.Protocol)
+			}
+			for _, host := range server.Hosts {
+				attributes["istio.gateway.host"] = extclient.AppendDistinct(attributes["istio.gateway.host"], host)
+			}
+			// Plaintext servers may have TLS settings just for the HTTPS redirect, their TLS mode is meaningless
+			if tls := server.GetTls(); tls != nil && isTLSProtocol(server.GetPort().GetProtocol()) {
+				attributes["istio.gateway.tls-mode"] = extclient.AppendDistinct(attributes["istio.gateway.tls-mode"], tls.Mode.String())
+				if tls.CredentialName != "" {
+					attributes["istio.gateway.credential-name"] = extclient.AppendDistinct(attributes["istio.gateway.credential-name"], tls.CredentialName)
+				}
+			}
+		}
+
+		for key, value := range gateway.Spec.Selector {
+			attributes["istio.gateway.selector."+key] = []string{value}
+		}
+
+		for key, value := range gateway.Labels {
+			attributes["k8s.gateway.label."+key] = []string{value}
+		}
+
+		result[i] = discovery_kit_api.Target{
+			Id:         fmt.Sprintf("%s/%s/%s", extconfig.Config.ClusterName, gateway.Namespace, gateway.Name),
+			Label:      gateway.Name,
+			TargetType: GatewayTargetID,
+			Attributes: attributes,
+		}
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(result, extconfig.Config.DiscoveryAttributesExcludesGateway)
+}
+
+func isTLSProtocol(protocol string) bool {
+	return strings.EqualFold(protocol, "HTTPS") || strings.EqualFold(protocol, "TLS")
+}
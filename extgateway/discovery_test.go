@@ -0,0 +1,102 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extgateway
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	testclient "istio.io/client-go/pkg/clientset/versioned/fake"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/features"
+	clientfeaturestesting "k8s.io/client-go/features/testing"
+	k8stestclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func Test_getDiscoveredGateways(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extconfig.Config.ClusterName = "development"
+	extconfig.Config.DiscoveryAttributesExcludesGateway = []string{"k8s.gateway.label.toIgnore"}
+
+	_, err := clientset.
+		NetworkingV1().
+		Gateways("istio-system").
+		Create(context.Background(), &apiv1.Gateway{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop-gateway",
+				Namespace: "istio-system",
+				Labels: map[string]string{
+					"best-city": "Kevelaer",
+					"toIgnore":  "Bielefeld",
+				},
+			},
+			Spec: networkingv1.Gateway{
+				Selector: map[string]string{"istio": "ingressgateway"},
+				Servers: []*networkingv1.Server{
+					{
+						Name:  "http",
+						Port:  &networkingv1.Port{Number: 80, Name: "http", Protocol: "HTTP"},
+						Hosts: []string{"shop.example.com"},
+						Tls:   &networkingv1.ServerTLSSettings{HttpsRedirect: true},
+					},
+					{
+						Name:  "https",
+						Port:  &networkingv1.Port{Number: 443, Name: "https", Protocol: "HTTPS"},
+						Hosts: []string{"shop.example.com", "api.example.com"},
+						Tls: &networkingv1.ServerTLSSettings{
+							Mode:           networkingv1.ServerTLSSettings_SIMPLE,
+							CredentialName: "shop-cert",
+						},
+					},
+				},
+			},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(getGatewayTargets(client)) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then
+	targets := getGatewayTargets(client)
+	require.Len(t, targets, 1)
+	target := targets[0]
+	require.Equal(t, "development/istio-system/shop-gateway", target.Id)
+	require.Equal(t, GatewayTargetID, target.TargetType)
+	require.Equal(t, "shop-gateway", target.Label)
+	require.Equal(t, map[string][]string{
+		"istio.gateway.name":            {"shop-gateway"},
+		"istio.gateway.server":          {"http", "https"},
+		"istio.gateway.port":            {"80", "443"},
+		"istio.gateway.protocol":        {"HTTP", "HTTPS"},
+		"istio.gateway.host":            {"shop.example.com", "api.example.com"},
+		"istio.gateway.tls-mode":        {"SIMPLE"},
+		"istio.gateway.credential-name": {"shop-cert"},
+		"istio.gateway.selector.istio":  {"ingressgateway"},
+		"k8s.namespace":                 {"istio-system"},
+		"k8s.cluster-name":              {"development"},
+		"k8s.gateway.label.best-city":   {"Kevelaer"},
+	}, target.Attributes)
+}
+
+func getTestClient(t testing.TB, stopCh <-chan struct{}) (*extclient.IstioClient, versionedClient.Interface) {
+	// Disable WatchListClient feature gate: the fake client doesn't support
+	// the bookmark events required by the WatchList stream, causing informers
+	// to hang indefinitely.
+	clientfeaturestesting.SetFeatureDuringTest(t, features.WatchListClient, false)
+	clientset := testclient.NewSimpleClientset()
+	client := extclient.NewIstioClient(clientset, k8stestclient.NewSimpleClientset(), stopCh)
+	return client, clientset
+}
@@ -0,0 +1,172 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	apisecurityv1 "istio.io/api/security/v1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"strings"
+)
+
+type AuthorizationPolicyActionState struct {
+	WorkloadActionState
+	PolicyName string
+	// SourceNamespaces and SourcePrincipals restrict the denied traffic, empty values deny the traffic of all sources.
+	SourceNamespaces []string
+	SourcePrincipals []string
+}
+
+type AuthorizationPolicyDenyAction struct {
+}
+
+func NewAuthorizationPolicyDenyAction() action_kit_sdk.Action[AuthorizationPolicyActionState] {
+	return AuthorizationPolicyDenyAction{}
+}
+
+var _ action_kit_sdk.Action[AuthorizationPolicyActionState] = (*AuthorizationPolicyDenyAction)(nil)
+var _ action_kit_sdk.ActionWithStop[AuthorizationPolicyActionState] = (*AuthorizationPolicyDenyAction)(nil)
+
+func (f AuthorizationPolicyDenyAction) NewEmptyState() AuthorizationPolicyActionState {
+	return AuthorizationPolicyActionState{}
+}
+
+func (f AuthorizationPolicyDenyAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.authorization-policy-deny", ServiceTargetID),
+		Label:           "Deny Traffic (Network Partition)",
+		Description:     "Denies the traffic to the workload behind the targeted Kubernetes service through a temporary DENY AuthorizationPolicy, optionally only for certain source namespaces or principals. Denied HTTP requests are answered with 403, other connections are reset.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the traffic should be denied."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:        "sourceNamespaces",
+				Label:       "Source namespaces",
+				Description: new("Deny only the traffic from workloads in these namespaces. Leave empty to deny the traffic from all sources."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
+				Order:       new(1),
+			},
+			{
+				Name:        "sourcePrincipals",
+				Label:       "Source principals",
+				Description: new("Deny only the traffic from these principals, e.g. 'cluster.local/ns/shop/sa/checkout'. Leave empty to deny the traffic from all sources."),
+				Type:        action_kit_api.ActionParameterTypeStringArray,
+				Required:    new(false),
+				Order:       new(2),
+				Hint: new(action_kit_api.ActionHint{
+					Type:    action_kit_api.HintInfo,
+					Content: "Source namespaces and principals are only known for traffic using mutual TLS.",
+				}),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f AuthorizationPolicyDenyAction) Prepare(_ context.Context, state *AuthorizationPolicyActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	if err := prepareWorkload(&state.WorkloadActionState, request); err != nil {
+		return nil, err
+	}
+	state.PolicyName = fmt.Sprintf("steadybit-%s", state.ExecutionId)
+	state.SourceNamespaces = extutil.ToStringArray(request.Config["sourceNamespaces"])
+	state.SourcePrincipals = extutil.ToStringArray(request.Config["sourcePrincipals"])
+	return nil, nil
+}
+
+func (f AuthorizationPolicyDenyAction) Start(ctx context.Context, state *AuthorizationPolicyActionState) (*action_kit_api.StartResult, error) {
+	err := extclient.Istio.CreateAuthorizationPolicy(ctx, toDenyAuthorizationPolicy(state))
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to create AuthorizationPolicy %s in namespace %s through Kubernetes API.", state.PolicyName, state.Namespace), err)
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{
+			{
+				Level:   new(action_kit_api.Info),
+				Message: fmt.Sprintf("Created AuthorizationPolicy %s in namespace %s denying %s for workloads with labels %s.", state.PolicyName, state.Namespace, state.describeSources(), extclient.FormatLabels(state.WorkloadLabels)),
+			},
+		},
+	}, nil
+}
+
+func (f AuthorizationPolicyDenyAction) Stop(ctx context.Context, state *AuthorizationPolicyActionState) (*action_kit_api.StopResult, error) {
+	err := extclient.Istio.DeleteAuthorizationPolicy(ctx, state.Namespace, state.PolicyName)
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to delete AuthorizationPolicy %s in namespace %s through Kubernetes API.", state.PolicyName, state.Namespace), err)
+	}
+	return nil, nil
+}
+
+// toDenyAuthorizationPolicy builds a DENY AuthorizationPolicy for the workload. Without a source restriction the
+// policy has a single empty rule, which matches all requests.
+func toDenyAuthorizationPolicy(state *AuthorizationPolicyActionState) *securityv1.AuthorizationPolicy {
+	rule := &apisecurityv1.Rule{}
+	if len(state.SourceNamespaces) > 0 || len(state.SourcePrincipals) > 0 {
+		rule.From = []*apisecurityv1.Rule_From{
+			{
+				Source: &apisecurityv1.Source{
+					Namespaces: state.SourceNamespaces,
+					Principals: state.SourcePrincipals,
+				},
+			},
+		}
+	}
+
+	return &securityv1.AuthorizationPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      state.PolicyName,
+			Namespace: state.Namespace,
+			Labels: map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			},
+		},
+		Spec: apisecurityv1.AuthorizationPolicy{
+			Selector: &typev1beta1.WorkloadSelector{
+				MatchLabels: state.WorkloadLabels,
+			},
+			Action: apisecurityv1.AuthorizationPolicy_DENY,
+			Rules:  []*apisecurityv1.Rule{rule},
+		},
+	}
+}
+
+func (state *AuthorizationPolicyActionState) describeSources() string {
+	var sources []string
+	if len(state.SourceNamespaces) > 0 {
+		sources = append(sources, "namespaces "+strings.Join(state.SourceNamespaces, ","))
+	}
+	if len(state.SourcePrincipals) > 0 {
+		sources = append(sources, "principals "+strings.Join(state.SourcePrincipals, ","))
+	}
+	if len(sources) == 0 {
+		return "all traffic"
+	}
+	return "traffic from " + strings.Join(sources, " and ")
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	apisecurityv1 "istio.io/api/security/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_authorizationPolicyDenyLifecycle(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        map[string]any
+		expectedRules []*apisecurityv1.Rule
+	}{
+		{
+			name:          "all sources",
+			config:        map[string]any{},
+			expectedRules: []*apisecurityv1.Rule{{}},
+		},
+		{
+			name: "source namespace and principal",
+			config: map[string]any{
+				"sourceNamespaces": []any{"checkout"},
+				"sourcePrincipals": []any{"cluster.local/ns/shop/sa/cart"},
+			},
+			expectedRules: []*apisecurityv1.Rule{
+				{
+					From: []*apisecurityv1.Rule_From{
+						{
+							Source: &apisecurityv1.Source{
+								Namespaces: []string{"checkout"},
+								Principals: []string{"cluster.local/ns/shop/sa/cart"},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh, getReviewsService())
+			extclient.Istio = client
+
+			// Prepare call
+			state := AuthorizationPolicyActionState{}
+			_, err := AuthorizationPolicyDenyAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(tt.config))
+			require.NoError(t, err)
+			require.Equal(t, "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", state.PolicyName)
+			require.Equal(t, map[string]string{"app": "reviews"}, state.WorkloadLabels)
+			state = extutil.JsonMangle(state)
+
+			// Start call
+			result, err := AuthorizationPolicyDenyAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			require.Len(t, *result.Messages, 1)
+
+			// Check that the AuthorizationPolicy was created
+			policy, err := clientset.
+				SecurityV1().
+				AuthorizationPolicies("shop").
+				Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, extclient.ManagedByValue, policy.Labels[extclient.ManagedByLabel])
+			require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", policy.Labels[extclient.ExecutionIdLabel])
+			require.Equal(t, apisecurityv1.AuthorizationPolicy_DENY, policy.Spec.Action)
+			require.Equal(t, map[string]string{"app": "reviews"}, policy.Spec.Selector.MatchLabels)
+			require.Len(t, policy.Spec.Rules, len(tt.expectedRules))
+			for i, rule := range tt.expectedRules {
+				require.True(t, proto.Equal(rule, policy.Spec.Rules[i]), "got %v", policy.Spec.Rules[i])
+			}
+
+			// Stop call
+			_, err = AuthorizationPolicyDenyAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that the AuthorizationPolicy was deleted
+			policies, err := clientset.
+				SecurityV1().
+				AuthorizationPolicies("").
+				List(context.Background(), v1.ListOptions{})
+			require.NoError(t, err)
+			require.Empty(t, policies.Items)
+		})
+	}
+}
+
+func Test_authorizationPolicyDenyRejectsServiceWithoutSelector(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, _ := getTestClient(t, stopCh, &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "shop"},
+	})
+	extclient.Istio = client
+
+	state := AuthorizationPolicyActionState{}
+	_, err := AuthorizationPolicyDenyAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{}))
+	require.ErrorContains(t, err, "Failed to find the workload behind Kubernetes service reviews in namespace shop")
+}
@@ -0,0 +1,23 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extvirtualservice"
+	networkingv1 "istio.io/api/networking/v1"
+)
+
+// prepareServiceFault injects the fault into the traffic to the host of the Kubernetes service. Without a
+// VirtualService for the host a temporary one is created next to the service, otherwise the fault is merged into the
+// routes of the existing ones.
+func prepareServiceFault(state *extvirtualservice.HostFaultActionState,
+	request action_kit_api.PrepareActionRequestBody,
+	toFault func(req action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection) error {
+
+	namespace := request.Target.Attributes["k8s.namespace"][0]
+	host := extclient.ToFullyQualifiedHost(namespace, request.Target.Attributes["k8s.service.name"][0])
+	return extvirtualservice.PrepareHostFault(state, request, namespace, []string{host}, toFault)
+}
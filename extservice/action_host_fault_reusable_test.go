@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extvirtualservice"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func Test_httpFaultLifecycle_createsVirtualService(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err := HttpDelayAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"delay":            5000.0,
+		"percentage":       50.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{map[string]any{"key": "x-chaos", "value": "true"}},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "shop", Name: "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", Host: "reviews.shop.svc.cluster.local", Temporary: true},
+	}, state.VirtualServices)
+	state = extutil.JsonMangle(state)
+
+	// Start call
+	_, err = HttpDelayAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the temporary VirtualService has the faulty route in front of the default route
+	vs, err := clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, vs.Labels[extclient.ManagedByLabel])
+	require.Equal(t, []string{"reviews.shop.svc.cluster.local"}, vs.Spec.Hosts)
+	require.Len(t, vs.Spec.Http, 2)
+	require.NotNil(t, vs.Spec.Http[0].Fault.GetDelay())
+	require.Equal(t, "true", vs.Spec.Http[0].Match[0].Headers["x-chaos"].GetExact())
+	require.Nil(t, vs.Spec.Http[1].Fault)
+	require.Empty(t, vs.Spec.Http[1].Match)
+	require.Equal(t, "reviews.shop.svc.cluster.local", vs.Spec.Http[1].Route[0].Destination.Host)
+
+	// Stop call
+	_, err = HttpDelayAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the temporary VirtualService was deleted
+	_, err = clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", v1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err))
+}
+
+func Test_httpFaultLifecycle_mergesIntoExistingVirtualService(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	existingSpec := &networkingv1.VirtualService{
+		Hosts: []string{"reviews.shop.svc.cluster.local"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "reviews", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "reviews", Subset: "v2"}}}},
+		},
+	}
+	_, err := clientset.NetworkingV1().VirtualServices("shop").Create(context.Background(), &apiv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews-route", Namespace: "shop"},
+		Spec:       *existingSpec.DeepCopy(),
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err = HttpAbortAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"statusCode":       503.0,
+		"percentage":       50.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "shop", Name: "reviews-route", Host: "reviews.shop.svc.cluster.local"},
+	}, state.VirtualServices)
+
+	// Start call
+	_, err = HttpAbortAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the fault was merged into the existing VirtualService instead of creating another one
+	vs, err := clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "reviews-route", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, vs.Spec.Http, 2)
+	require.Equal(t, int32(503), vs.Spec.Http[0].Fault.GetAbort().GetHttpStatus())
+	require.Equal(t, "v2", vs.Spec.Http[0].Route[0].Destination.Subset)
+	virtualServices, err := clientset.NetworkingV1().VirtualServices("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, virtualServices.Items, 1)
+
+	// Stop call
+	_, err = HttpAbortAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the existing VirtualService was restored
+	vs, err = clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "reviews-route", v1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(existingSpec, &vs.Spec), "got %v", &vs.Spec)
+}
+
+func Test_httpFaultLifecycle_restrictsVirtualServiceForOtherServices(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	existingSpec := &networkingv1.VirtualService{
+		Hosts: []string{"reviews", "ratings"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "all", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "reviews"}}}},
+		},
+	}
+	_, err := clientset.NetworkingV1().VirtualServices("shop").Create(context.Background(), &apiv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{Name: "shop-routes", Namespace: "shop"},
+		Spec:       *existingSpec.DeepCopy(),
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err = HttpAbortAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"statusCode":       503.0,
+		"percentage":       100.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "shop", Name: "shop-routes", Host: "reviews.shop.svc.cluster.local", Restricted: true},
+	}, state.VirtualServices)
+	state = extutil.JsonMangle(state)
+
+	// Start call
+	_, err = HttpAbortAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that only the requests for the targeted service are faulted, including the ones using its short names
+	vs, err := clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "shop-routes", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Len(t, vs.Spec.Http, 2)
+	require.Equal(t, `reviews(\.shop(\.svc(\.cluster\.local)?)?)?(:[0-9]+)?`, vs.Spec.Http[0].Match[0].Authority.GetRegex())
+	require.NotNil(t, vs.Spec.Http[0].Fault)
+	require.Equal(t, "all", vs.Spec.Http[1].Name)
+	require.Nil(t, vs.Spec.Http[1].Fault)
+
+	// Stop call
+	_, err = HttpAbortAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the existing VirtualService was restored
+	vs, err = clientset.NetworkingV1().VirtualServices("shop").Get(context.Background(), "shop-routes", v1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(existingSpec, &vs.Spec), "got %v", &vs.Spec)
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extvirtualservice"
+	"github.com/steadybit/extension-kit/extbuild"
+)
+
+type HttpAbortAction struct {
+}
+
+func NewHttpAbortAction() action_kit_sdk.Action[extvirtualservice.HostFaultActionState] {
+	return HttpAbortAction{}
+}
+
+var _ action_kit_sdk.Action[extvirtualservice.HostFaultActionState] = (*HttpAbortAction)(nil)
+var _ action_kit_sdk.ActionWithStop[extvirtualservice.HostFaultActionState] = (*HttpAbortAction)(nil)
+
+func (f HttpAbortAction) NewEmptyState() extvirtualservice.HostFaultActionState {
+	return extvirtualservice.HostFaultActionState{}
+}
+
+func (f HttpAbortAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.http.abort", ServiceTargetID),
+		Label:           "HTTP Abort",
+		Description:     "Injects a HTTP abort fault into the traffic to the host of the targeted Kubernetes services. Existing virtual services for the host get the fault added to their HTTP routes, otherwise a temporary virtual service with a default route is created.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters:      extvirtualservice.GetHostAbortParameters(),
+		Prepare:         action_kit_api.MutatingEndpointReference{},
+		Start:           action_kit_api.MutatingEndpointReference{},
+		Stop:            new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpAbortAction) Prepare(_ context.Context, state *extvirtualservice.HostFaultActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareServiceFault(state, request, extvirtualservice.ToHTTPAbortFault)
+}
+
+func (f HttpAbortAction) Start(ctx context.Context, state *extvirtualservice.HostFaultActionState) (*action_kit_api.StartResult, error) {
+	return extvirtualservice.StartHostFault(ctx, state)
+}
+
+func (f HttpAbortAction) Stop(ctx context.Context, state *extvirtualservice.HostFaultActionState) (*action_kit_api.StopResult, error) {
+	return nil, extvirtualservice.StopHostFault(ctx, state)
+}
@@ -0,0 +1,381 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	apisecurityv1 "istio.io/api/security/v1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+)
+
+const (
+	jwtBreakageModeKeys       = "keys"
+	jwtBreakageModeIssuer     = "issuer"
+	jwtBreakageModeRequireJwt = "requireJwt"
+
+	// requestAuthenticationRootNamespace is Istio's default root namespace, its RequestAuthentications without workload
+	// selector apply to all workloads of the mesh.
+	requestAuthenticationRootNamespace = "istio-system"
+)
+
+type JwtBreakageActionState struct {
+	WorkloadActionState
+	Mode    string
+	JwksUri string
+	Issuer  string
+	// PolicyName is the name of the RequestAuthentication and the AuthorizationPolicy created for the mode requireJwt.
+	PolicyName string
+	// RequestAuthentications are the existing resources modified for the modes keys and issuer.
+	RequestAuthentications []ModifiedRequestAuthentication
+}
+
+type ModifiedRequestAuthentication struct {
+	Namespace        string
+	Name             string
+	Applied          bool
+	OriginalJwtRules []*apisecurityv1.JWTRule
+}
+
+type JwtBreakageAction struct {
+}
+
+func NewJwtBreakageAction() action_kit_sdk.Action[JwtBreakageActionState] {
+	return JwtBreakageAction{}
+}
+
+var _ action_kit_sdk.Action[JwtBreakageActionState] = (*JwtBreakageAction)(nil)
+var _ action_kit_sdk.ActionWithStop[JwtBreakageActionState] = (*JwtBreakageAction)(nil)
+
+func (f JwtBreakageAction) NewEmptyState() JwtBreakageActionState {
+	return JwtBreakageActionState{}
+}
+
+func (f JwtBreakageAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.jwt-breakage", ServiceTargetID),
+		Label:           "Break JWT Authentication",
+		Description:     "Breaks the JWT authentication of the workload behind the targeted Kubernetes service, emulating an outage of the identity provider or a failed key rotation. Requests with a token that can no longer be validated are rejected with 401 by the RequestAuthentication. Requests without a token are only rejected when a JWT is required, then with 403 by the AuthorizationPolicy. Requiring a JWT is refused for workloads with a RequestAuthentication already, as it would still accept the tokens of their issuers.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the JWT authentication should be broken."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "mode",
+				Label:        "Mode",
+				Description:  new("How the JWT authentication should be broken."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(jwtBreakageModeKeys),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Point existing request authentications to invalid keys",
+						Value: jwtBreakageModeKeys,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Change the issuer of existing request authentications",
+						Value: jwtBreakageModeIssuer,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Require a JWT of an unknown issuer",
+						Value: jwtBreakageModeRequireJwt,
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+			{
+				Name:         "jwksUri",
+				Label:        "JWKS URI",
+				Description:  new("URI the public keys for validating tokens are fetched from instead."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new("https://jwks.steadybit.invalid/keys"),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "issuer",
+				Label:        "Issuer",
+				Description:  new("Issuer used instead. Not used for the mode 'Point existing request authentications to invalid keys'."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new("https://issuer.steadybit.invalid"),
+				Required:     new(true),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f JwtBreakageAction) Prepare(_ context.Context, state *JwtBreakageActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.Mode = extutil.ToString(request.Config["mode"])
+	state.JwksUri = extutil.ToString(request.Config["jwksUri"])
+	state.Issuer = extutil.ToString(request.Config["issuer"])
+
+	if !slices.Contains([]string{jwtBreakageModeKeys, jwtBreakageModeIssuer, jwtBreakageModeRequireJwt}, state.Mode) {
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("unknown mode '%s'", state.Mode))
+	}
+	if state.JwksUri == "" || state.Issuer == "" {
+		return nil, extension_kit.ToError("Failed prepare attack", errors.New("JWKS URI and issuer are required"))
+	}
+	if err := prepareWorkload(&state.WorkloadActionState, request); err != nil {
+		return nil, err
+	}
+	state.PolicyName = fmt.Sprintf("steadybit-%s", state.ExecutionId)
+	return nil, nil
+}
+
+func (f JwtBreakageAction) Start(ctx context.Context, state *JwtBreakageActionState) (*action_kit_api.StartResult, error) {
+	if state.Mode == jwtBreakageModeRequireJwt {
+		return startRequireJwt(ctx, state)
+	}
+	return startRequestAuthenticationChange(ctx, state)
+}
+
+func (f JwtBreakageAction) Stop(ctx context.Context, state *JwtBreakageActionState) (*action_kit_api.StopResult, error) {
+	var err error
+	if state.Mode == jwtBreakageModeRequireJwt {
+		err = deleteRequireJwt(ctx, state)
+	} else {
+		err = restoreRequestAuthentications(ctx, state)
+	}
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to restore the JWT authentication for Kubernetes service %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	return nil, nil
+}
+
+// startRequestAuthenticationChange breaks the JWT rules of all RequestAuthentications applying to the workload. Their
+// original rules are snapshotted into the state, so that they can be restored exactly on stop. The
+// RequestAuthentications are marked as modified, to refuse overlapping attacks on them.
+func startRequestAuthenticationChange(ctx context.Context, state *JwtBreakageActionState) (*action_kit_api.StartResult, error) {
+	requestAuthentications, err := extclient.Istio.ListRequestAuthentications(ctx, state.Namespace)
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to fetch RequestAuthentications in namespace %s through Kubernetes API.", state.Namespace), err)
+	}
+	state.RequestAuthentications = nil
+	for _, ra := range requestAuthentications {
+		if selectsWorkload(ra.Spec.GetSelector(), state.WorkloadLabels) {
+			state.RequestAuthentications = append(state.RequestAuthentications, ModifiedRequestAuthentication{Namespace: ra.Namespace, Name: ra.Name})
+		}
+	}
+	if len(state.RequestAuthentications) == 0 {
+		return nil, extension_kit.ToError(fmt.Sprintf("No RequestAuthentication applies to the workload behind Kubernetes service %s in namespace %s.", state.Name, state.Namespace), nil)
+	}
+
+	messages := make([]action_kit_api.Message, 0, len(state.RequestAuthentications))
+	for i := range state.RequestAuthentications {
+		target := &state.RequestAuthentications[i]
+		err := extclient.Istio.UpdateRequestAuthentication(ctx, target.Namespace, target.Name, func(ra *securityv1.RequestAuthentication) error {
+			if err := extclient.MarkModified(ra, state.ExecutionId); err != nil {
+				return err
+			}
+			target.OriginalJwtRules = cloneJwtRules(ra.Spec.JwtRules)
+			for _, jwtRule := range ra.Spec.JwtRules {
+				if state.Mode == jwtBreakageModeIssuer {
+					jwtRule.Issuer = state.Issuer
+				}
+				jwtRule.JwksUri = state.JwksUri
+				jwtRule.Jwks = ""
+			}
+			return nil
+		})
+		if err != nil {
+			_ = restoreRequestAuthentications(ctx, state)
+			return nil, extension_kit.ToError(fmt.Sprintf("Failed to modify RequestAuthentication %s in namespace %s through Kubernetes API.", target.Name, target.Namespace), err)
+		}
+		target.Applied = true
+
+		messages = append(messages, action_kit_api.Message{
+			Level:   new(action_kit_api.Info),
+			Message: fmt.Sprintf("Broke the JWT rules of RequestAuthentication %s in namespace %s.", target.Name, target.Namespace),
+		})
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &messages,
+	}, nil
+}
+
+func restoreRequestAuthentications(ctx context.Context, state *JwtBreakageActionState) error {
+	var errs []error
+	for i := range state.RequestAuthentications {
+		target := &state.RequestAuthentications[i]
+		if !target.Applied {
+			continue
+		}
+		err := extclient.Istio.UpdateRequestAuthentication(ctx, target.Namespace, target.Name, func(ra *securityv1.RequestAuthentication) error {
+			extclient.UnmarkModified(ra, state.ExecutionId)
+			ra.Spec.JwtRules = cloneJwtRules(target.OriginalJwtRules)
+			return nil
+		})
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		target.Applied = false
+	}
+	return errors.Join(errs...)
+}
+
+// startRequireJwt creates a RequestAuthentication accepting only tokens of the unknown issuer, and an
+// AuthorizationPolicy denying requests without such a token, for the workload. Istio accepts a token valid for any
+// RequestAuthentication of the workload, so the attack is refused if one applies already.
+func startRequireJwt(ctx context.Context, state *JwtBreakageActionState) (*action_kit_api.StartResult, error) {
+	existing, err := findRequestAuthentication(ctx, state)
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to fetch RequestAuthentications for namespace %s through Kubernetes API.", state.Namespace), err)
+	}
+	if existing != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("RequestAuthentication %s in namespace %s applies to the workload behind Kubernetes service %s already and still accepts tokens of its issuers. Break it through its keys or issuer instead.", existing.Name, existing.Namespace, state.Name), nil)
+	}
+
+	err = extclient.Istio.CreateRequestAuthentication(ctx, toRequestAuthentication(state))
+	if err == nil {
+		err = extclient.Istio.CreateAuthorizationPolicy(ctx, toRequireJwtAuthorizationPolicy(state))
+	}
+	if err != nil {
+		_ = deleteRequireJwt(ctx, state)
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to require a JWT for workloads with labels %s in namespace %s through Kubernetes API.", extclient.FormatLabels(state.WorkloadLabels), state.Namespace), err)
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{
+			{
+				Level:   new(action_kit_api.Info),
+				Message: fmt.Sprintf("Created RequestAuthentication and AuthorizationPolicy %s in namespace %s requiring a JWT of issuer %s for workloads with labels %s.", state.PolicyName, state.Namespace, state.Issuer, extclient.FormatLabels(state.WorkloadLabels)),
+			},
+		},
+	}, nil
+}
+
+// findRequestAuthentication returns a RequestAuthentication applying to the workload, from its namespace or the root
+// namespace. The ones created by other attacks are skipped, they only accept tokens of unknown issuers.
+func findRequestAuthentication(ctx context.Context, state *JwtBreakageActionState) (*securityv1.RequestAuthentication, error) {
+	namespaces := []string{state.Namespace}
+	if state.Namespace != requestAuthenticationRootNamespace {
+		namespaces = append(namespaces, requestAuthenticationRootNamespace)
+	}
+
+	for _, namespace := range namespaces {
+		requestAuthentications, err := extclient.Istio.ListRequestAuthentications(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+		for _, ra := range requestAuthentications {
+			if ra.Labels[extclient.ManagedByLabel] == extclient.ManagedByValue {
+				continue
+			}
+			if namespace != state.Namespace && ra.Spec.GetSelector() != nil {
+				continue
+			}
+			if selectsWorkload(ra.Spec.GetSelector(), state.WorkloadLabels) {
+				return ra, nil
+			}
+		}
+	}
+	return nil, nil
+}
+
+func deleteRequireJwt(ctx context.Context, state *JwtBreakageActionState) error {
+	return errors.Join(
+		extclient.Istio.DeleteAuthorizationPolicy(ctx, state.Namespace, state.PolicyName),
+		extclient.Istio.DeleteRequestAuthentication(ctx, state.Namespace, state.PolicyName),
+	)
+}
+
+func toRequestAuthentication(state *JwtBreakageActionState) *securityv1.RequestAuthentication {
+	return &securityv1.RequestAuthentication{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      state.PolicyName,
+			Namespace: state.Namespace,
+			Labels: map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			},
+		},
+		Spec: apisecurityv1.RequestAuthentication{
+			Selector: &typev1beta1.WorkloadSelector{
+				MatchLabels: state.WorkloadLabels,
+			},
+			JwtRules: []*apisecurityv1.JWTRule{
+				{
+					Issuer:  state.Issuer,
+					JwksUri: state.JwksUri,
+				},
+			},
+		},
+	}
+}
+
+func toRequireJwtAuthorizationPolicy(state *JwtBreakageActionState) *securityv1.AuthorizationPolicy {
+	return &securityv1.AuthorizationPolicy{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      state.PolicyName,
+			Namespace: state.Namespace,
+			Labels: map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			},
+		},
+		Spec: apisecurityv1.AuthorizationPolicy{
+			Selector: &typev1beta1.WorkloadSelector{
+				MatchLabels: state.WorkloadLabels,
+			},
+			Action: apisecurityv1.AuthorizationPolicy_DENY,
+			Rules: []*apisecurityv1.Rule{
+				{
+					From: []*apisecurityv1.Rule_From{
+						{Source: &apisecurityv1.Source{NotRequestPrincipals: []string{"*"}}},
+					},
+				},
+			},
+		},
+	}
+}
+
+// selectsWorkload tells whether a policy with the given selector applies to the workload. Policies without selector
+// apply to the whole namespace.
+func selectsWorkload(selector *typev1beta1.WorkloadSelector, workloadLabels map[string]string) bool {
+	for key, value := range selector.GetMatchLabels() {
+		if workloadLabels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func cloneJwtRules(jwtRules []*apisecurityv1.JWTRule) []*apisecurityv1.JWTRule {
+	if jwtRules == nil {
+		return nil
+	}
+	result := make([]*apisecurityv1.JWTRule, len(jwtRules))
+	for i, jwtRule := range jwtRules {
+		result[i] = jwtRule.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	apisecurityv1 "istio.io/api/security/v1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_jwtBreakageLifecycle_modifiesRequestAuthentications(t *testing.T) {
+	tests := []struct {
+		name           string
+		mode           string
+		expectedIssuer string
+	}{
+		{name: "keys", mode: "keys", expectedIssuer: "https://accounts.example.com"},
+		{name: "issuer", mode: "issuer", expectedIssuer: "https://issuer.steadybit.invalid"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh, getReviewsService())
+			extclient.Istio = client
+
+			originalSpec := &apisecurityv1.RequestAuthentication{
+				Selector: &typev1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "reviews"}},
+				JwtRules: []*apisecurityv1.JWTRule{
+					{Issuer: "https://accounts.example.com", Jwks: "{\"keys\":[]}", Audiences: []string{"shop"}},
+				},
+			}
+			for name, spec := range map[string]*apisecurityv1.RequestAuthentication{
+				"reviews": originalSpec,
+				"ratings": {Selector: &typev1beta1.WorkloadSelector{MatchLabels: map[string]string{"app": "ratings"}}},
+			} {
+				_, err := clientset.SecurityV1().RequestAuthentications("shop").Create(context.Background(), &securityv1.RequestAuthentication{
+					ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "shop"},
+					Spec:       *spec.DeepCopy(),
+				}, v1.CreateOptions{})
+				require.NoError(t, err)
+			}
+
+			// Prepare call
+			state := JwtBreakageActionState{}
+			_, err := JwtBreakageAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+				"mode":    tt.mode,
+				"jwksUri": "https://jwks.steadybit.invalid/keys",
+				"issuer":  "https://issuer.steadybit.invalid",
+			}))
+			require.NoError(t, err)
+
+			// Start call
+			result, err := JwtBreakageAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			require.Len(t, *result.Messages, 1)
+			state = extutil.JsonMangle(state)
+
+			// Check that only the RequestAuthentication of the workload was broken
+			ra, err := clientset.SecurityV1().RequestAuthentications("shop").Get(context.Background(), "reviews", v1.GetOptions{})
+			require.NoError(t, err)
+			require.Len(t, ra.Spec.JwtRules, 1)
+			require.Equal(t, tt.expectedIssuer, ra.Spec.JwtRules[0].Issuer)
+			require.Equal(t, "https://jwks.steadybit.invalid/keys", ra.Spec.JwtRules[0].JwksUri)
+			require.Empty(t, ra.Spec.JwtRules[0].Jwks)
+			require.Equal(t, []string{"shop"}, ra.Spec.JwtRules[0].Audiences)
+			require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", ra.Annotations[extclient.ModifiedByAnnotation])
+
+			// Stop call
+			_, err = JwtBreakageAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that the exact original spec was restored
+			ra, err = clientset.SecurityV1().RequestAuthentications("shop").Get(context.Background(), "reviews", v1.GetOptions{})
+			require.NoError(t, err)
+			require.True(t, proto.Equal(originalSpec, &ra.Spec), "got %v", &ra.Spec)
+			require.Empty(t, ra.Annotations)
+		})
+	}
+}
+
+func Test_jwtBreakageLifecycle_requiresJwt(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh, getReviewsService())
+	extclient.Istio = client
+
+	// Prepare call
+	state := JwtBreakageActionState{}
+	_, err := JwtBreakageAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"mode":    "requireJwt",
+		"jwksUri": "https://jwks.steadybit.invalid/keys",
+		"issuer":  "https://issuer.steadybit.invalid",
+	}))
+	require.NoError(t, err)
+
+	// Start call
+	_, err = JwtBreakageAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the RequestAuthentication and the AuthorizationPolicy were created
+	ra, err := clientset.SecurityV1().RequestAuthentications("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, ra.Labels[extclient.ManagedByLabel])
+	require.Equal(t, map[string]string{"app": "reviews"}, ra.Spec.Selector.MatchLabels)
+	require.Equal(t, "https://issuer.steadybit.invalid", ra.Spec.JwtRules[0].Issuer)
+	policy, err := clientset.SecurityV1().AuthorizationPolicies("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, policy.Labels[extclient.ManagedByLabel])
+	require.Equal(t, apisecurityv1.AuthorizationPolicy_DENY, policy.Spec.Action)
+	require.Equal(t, []string{"*"}, policy.Spec.Rules[0].From[0].Source.NotRequestPrincipals)
+
+	// Stop call
+	_, err = JwtBreakageAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that both resources were deleted
+	requestAuthentications, err := clientset.SecurityV1().RequestAuthentications("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, requestAuthentications.Items)
+	policies, err := clientset.SecurityV1().AuthorizationPolicies("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, policies.Items)
+}
+
+func Test_jwtBreakageStart_requireJwtWithRequestAuthentication(t *testing.T) {
+	for _, namespace := range []string{"shop", "istio-system"} {
+		t.Run(namespace, func(t *testing.T) {
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh, getReviewsService())
+			extclient.Istio = client
+
+			_, err := clientset.SecurityV1().RequestAuthentications(namespace).Create(context.Background(), &securityv1.RequestAuthentication{
+				ObjectMeta: v1.ObjectMeta{Name: "jwt", Namespace: namespace},
+				Spec: apisecurityv1.RequestAuthentication{
+					JwtRules: []*apisecurityv1.JWTRule{{Issuer: "https://issuer.shop.local", JwksUri: "https://issuer.shop.local/keys"}},
+				},
+			}, v1.CreateOptions{})
+			require.NoError(t, err)
+
+			state := JwtBreakageActionState{}
+			_, err = JwtBreakageAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+				"mode":    "requireJwt",
+				"jwksUri": "https://jwks.steadybit.invalid/keys",
+				"issuer":  "https://issuer.steadybit.invalid",
+			}))
+			require.NoError(t, err)
+
+			// Check that the attack is refused, as the existing RequestAuthentication still accepts valid tokens
+			_, err = JwtBreakageAction{}.Start(context.Background(), &state)
+			require.ErrorContains(t, err, "RequestAuthentication jwt in namespace "+namespace+" applies to the workload")
+			requestAuthentications, err := clientset.SecurityV1().RequestAuthentications("").List(context.Background(), v1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, requestAuthentications.Items, 1)
+		})
+	}
+}
+
+func Test_jwtBreakageStart_withoutRequestAuthentication(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, _ := getTestClient(t, stopCh, getReviewsService())
+	extclient.Istio = client
+
+	state := JwtBreakageActionState{}
+	_, err := JwtBreakageAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"mode":    "keys",
+		"jwksUri": "https://jwks.steadybit.invalid/keys",
+		"issuer":  "https://issuer.steadybit.invalid",
+	}))
+	require.NoError(t, err)
+
+	_, err = JwtBreakageAction{}.Start(context.Background(), &state)
+	require.ErrorContains(t, err, "No RequestAuthentication applies")
+}
@@ -0,0 +1,268 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	apisecurityv1 "istio.io/api/security/v1"
+	typev1beta1 "istio.io/api/type/v1beta1"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+const (
+	peerAuthenticationScopeNamespace = "namespace"
+	peerAuthenticationScopeWorkload  = "workload"
+)
+
+// PeerAuthenticationActionState describes the PeerAuthentication switched to STRICT. Created tells whether the
+// extension created the resource, otherwise the original mTLS settings of the existing resource are restored on stop.
+type PeerAuthenticationActionState struct {
+	WorkloadActionState
+	Scope                 string
+	PolicyName            string
+	Created               bool
+	Applied               bool
+	OriginalMtls          *apisecurityv1.PeerAuthentication_MutualTLS
+	OriginalPortLevelMtls map[uint32]*apisecurityv1.PeerAuthentication_MutualTLS
+}
+
+type PeerAuthenticationStrictAction struct {
+}
+
+func NewPeerAuthenticationStrictAction() action_kit_sdk.Action[PeerAuthenticationActionState] {
+	return PeerAuthenticationStrictAction{}
+}
+
+var _ action_kit_sdk.Action[PeerAuthenticationActionState] = (*PeerAuthenticationStrictAction)(nil)
+var _ action_kit_sdk.ActionWithStop[PeerAuthenticationActionState] = (*PeerAuthenticationStrictAction)(nil)
+
+func (f PeerAuthenticationStrictAction) NewEmptyState() PeerAuthenticationActionState {
+	return PeerAuthenticationActionState{}
+}
+
+func (f PeerAuthenticationStrictAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.peer-authentication-strict", ServiceTargetID),
+		Label:           "Enforce Strict mTLS",
+		Description:     "Switches the PeerAuthentication of the workload behind the targeted Kubernetes service, or of its namespace, to STRICT mutual TLS. Clients without a sidecar, e.g. legacy VMs or jobs, can no longer connect.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long strict mTLS should be enforced."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "scope",
+				Label:        "Scope",
+				Description:  new("Whether strict mTLS is enforced for the namespace of the workload, or for the workload only."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(peerAuthenticationScopeWorkload),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Workload",
+						Value: peerAuthenticationScopeWorkload,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Namespace",
+						Value: peerAuthenticationScopeNamespace,
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f PeerAuthenticationStrictAction) Prepare(_ context.Context, state *PeerAuthenticationActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.Scope = extutil.ToString(request.Config["scope"])
+	if state.Scope != peerAuthenticationScopeNamespace && state.Scope != peerAuthenticationScopeWorkload {
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("unknown scope '%s'", state.Scope))
+	}
+	if err := prepareWorkload(&state.WorkloadActionState, request); err != nil {
+		return nil, err
+	}
+	return nil, nil
+}
+
+func (f PeerAuthenticationStrictAction) Start(ctx context.Context, state *PeerAuthenticationActionState) (*action_kit_api.StartResult, error) {
+	message, err := enforceStrictMtls(ctx, state)
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to enforce strict mTLS in namespace %s through Kubernetes API.", state.Namespace), err)
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{
+			{
+				Level:   new(action_kit_api.Info),
+				Message: message,
+			},
+		},
+	}, nil
+}
+
+func (f PeerAuthenticationStrictAction) Stop(ctx context.Context, state *PeerAuthenticationActionState) (*action_kit_api.StopResult, error) {
+	var err error
+	switch {
+	case state.Created:
+		err = extclient.Istio.DeletePeerAuthentication(ctx, state.Namespace, state.PolicyName)
+	case state.Applied:
+		err = extclient.Istio.UpdatePeerAuthentication(ctx, state.Namespace, state.PolicyName, func(pa *securityv1.PeerAuthentication) error {
+			extclient.UnmarkModified(pa, state.ExecutionId)
+			pa.Spec.Mtls = state.OriginalMtls.DeepCopy()
+			pa.Spec.PortLevelMtls = clonePortLevelMtls(state.OriginalPortLevelMtls)
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to restore PeerAuthentication %s in namespace %s through Kubernetes API.", state.PolicyName, state.Namespace), err)
+	}
+	return nil, nil
+}
+
+// enforceStrictMtls switches the existing PeerAuthentication for the workload or namespace to STRICT, or creates one
+// if there is none. Port level settings are dropped, as they could keep ports permissive. An existing
+// PeerAuthentication is marked as modified, to refuse overlapping attacks on it.
+func enforceStrictMtls(ctx context.Context, state *PeerAuthenticationActionState) (string, error) {
+	existing, err := findPeerAuthentication(ctx, state)
+	if err != nil {
+		return "", err
+	}
+
+	if existing == nil {
+		state.PolicyName = fmt.Sprintf("steadybit-%s", state.ExecutionId)
+		err = extclient.Istio.CreatePeerAuthentication(ctx, toStrictPeerAuthentication(state))
+		if err != nil {
+			return "", err
+		}
+		state.Created = true
+		return fmt.Sprintf("Created strict PeerAuthentication %s in namespace %s%s.", state.PolicyName, state.Namespace, state.describeWorkloads()), nil
+	}
+
+	state.PolicyName = existing.Name
+	err = extclient.Istio.UpdatePeerAuthentication(ctx, state.Namespace, state.PolicyName, func(pa *securityv1.PeerAuthentication) error {
+		if err := extclient.MarkModified(pa, state.ExecutionId); err != nil {
+			return err
+		}
+		state.OriginalMtls = pa.Spec.Mtls.DeepCopy()
+		state.OriginalPortLevelMtls = clonePortLevelMtls(pa.Spec.PortLevelMtls)
+		pa.Spec.Mtls = &apisecurityv1.PeerAuthentication_MutualTLS{Mode: apisecurityv1.PeerAuthentication_MutualTLS_STRICT}
+		pa.Spec.PortLevelMtls = nil
+		return nil
+	})
+	if err != nil {
+		return "", err
+	}
+	state.Applied = true
+	return fmt.Sprintf("Switched PeerAuthentication %s in namespace %s to strict mTLS%s.", state.PolicyName, state.Namespace, state.describeWorkloads()), nil
+}
+
+// findPeerAuthentication returns the PeerAuthentication Istio applies for the scope, i.e. the oldest one selecting
+// the workload labels, or the oldest one without selector for the namespace.
+func findPeerAuthentication(ctx context.Context, state *PeerAuthenticationActionState) (*securityv1.PeerAuthentication, error) {
+	peerAuthentications, err := extclient.Istio.ListPeerAuthentications(ctx, state.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *securityv1.PeerAuthentication
+	for _, pa := range peerAuthentications {
+		if !state.matches(pa) {
+			continue
+		}
+		if result == nil || pa.CreationTimestamp.Before(&result.CreationTimestamp) {
+			result = pa
+		}
+	}
+	return result, nil
+}
+
+func toStrictPeerAuthentication(state *PeerAuthenticationActionState) *securityv1.PeerAuthentication {
+	pa := &securityv1.PeerAuthentication{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      state.PolicyName,
+			Namespace: state.Namespace,
+			Labels: map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			},
+			// Marked like modified ones, so overlapping attacks refuse to switch the created PeerAuthentication too.
+			Annotations: map[string]string{
+				extclient.ModifiedByAnnotation: state.ExecutionId,
+			},
+		},
+		Spec: apisecurityv1.PeerAuthentication{
+			Mtls: &apisecurityv1.PeerAuthentication_MutualTLS{Mode: apisecurityv1.PeerAuthentication_MutualTLS_STRICT},
+		},
+	}
+	if labels := state.selectorLabels(); len(labels) > 0 {
+		pa.Spec.Selector = &typev1beta1.WorkloadSelector{MatchLabels: labels}
+	}
+	return pa
+}
+
+// selectorLabels returns the workload labels for the workload scope, and none for the namespace scope.
+func (state *PeerAuthenticationActionState) selectorLabels() map[string]string {
+	if state.Scope == peerAuthenticationScopeWorkload {
+		return state.WorkloadLabels
+	}
+	return nil
+}
+
+func (state *PeerAuthenticationActionState) matches(pa *securityv1.PeerAuthentication) bool {
+	selector := pa.Spec.GetSelector().GetMatchLabels()
+	labels := state.selectorLabels()
+	if len(labels) == 0 {
+		return len(selector) == 0
+	}
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (state *PeerAuthenticationActionState) describeWorkloads() string {
+	labels := state.selectorLabels()
+	if len(labels) == 0 {
+		return ""
+	}
+	return " for workloads with labels " + extclient.FormatLabels(labels)
+}
+
+func clonePortLevelMtls(portLevelMtls map[uint32]*apisecurityv1.PeerAuthentication_MutualTLS) map[uint32]*apisecurityv1.PeerAuthentication_MutualTLS {
+	if portLevelMtls == nil {
+		return nil
+	}
+	result := make(map[uint32]*apisecurityv1.PeerAuthentication_MutualTLS, len(portLevelMtls))
+	for port, mtls := range portLevelMtls {
+		result[port] = mtls.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,116 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	apisecurityv1 "istio.io/api/security/v1"
+	securityv1 "istio.io/client-go/pkg/apis/security/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_peerAuthenticationStrictLifecycle_createsPolicy(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh, getReviewsService())
+	extclient.Istio = client
+
+	// Prepare call
+	state := PeerAuthenticationActionState{}
+	_, err := PeerAuthenticationStrictAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{"scope": "workload"}))
+	require.NoError(t, err)
+
+	// Start call
+	_, err = PeerAuthenticationStrictAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that a strict PeerAuthentication was created for the workload
+	pa, err := clientset.SecurityV1().PeerAuthentications("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, pa.Labels[extclient.ManagedByLabel])
+	require.Equal(t, map[string]string{"app": "reviews"}, pa.Spec.Selector.MatchLabels)
+	require.Equal(t, apisecurityv1.PeerAuthentication_MutualTLS_STRICT, pa.Spec.Mtls.Mode)
+
+	// Check that an overlapping attack does not adopt the created PeerAuthentication
+	otherState := PeerAuthenticationActionState{}
+	_, err = PeerAuthenticationStrictAction{}.Prepare(context.Background(), &otherState, getTestPrepareRequest(map[string]any{"scope": "workload"}))
+	require.NoError(t, err)
+	otherState.ExecutionId = "7b1f7e7a-7c07-4d39-a0c6-cbc3cd6c1a55"
+	_, err = PeerAuthenticationStrictAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+
+	// Stop call
+	_, err = PeerAuthenticationStrictAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the PeerAuthentication was deleted
+	policies, err := clientset.SecurityV1().PeerAuthentications("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, policies.Items)
+}
+
+func Test_peerAuthenticationStrictLifecycle_modifiesPolicy(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh, getReviewsService())
+	extclient.Istio = client
+
+	originalSpec := &apisecurityv1.PeerAuthentication{
+		Mtls: &apisecurityv1.PeerAuthentication_MutualTLS{Mode: apisecurityv1.PeerAuthentication_MutualTLS_PERMISSIVE},
+		PortLevelMtls: map[uint32]*apisecurityv1.PeerAuthentication_MutualTLS{
+			8080: {Mode: apisecurityv1.PeerAuthentication_MutualTLS_DISABLE},
+		},
+	}
+	_, err := clientset.SecurityV1().PeerAuthentications("shop").Create(context.Background(), &securityv1.PeerAuthentication{
+		ObjectMeta: v1.ObjectMeta{Name: "default", Namespace: "shop"},
+		Spec:       *originalSpec.DeepCopy(),
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Prepare call
+	state := PeerAuthenticationActionState{}
+	_, err = PeerAuthenticationStrictAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{"scope": "namespace"}))
+	require.NoError(t, err)
+
+	// Start call
+	_, err = PeerAuthenticationStrictAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that the existing namespace wide PeerAuthentication was switched to strict
+	pa, err := clientset.SecurityV1().PeerAuthentications("shop").Get(context.Background(), "default", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, apisecurityv1.PeerAuthentication_MutualTLS_STRICT, pa.Spec.Mtls.Mode)
+	require.Empty(t, pa.Spec.PortLevelMtls)
+	require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", pa.Annotations[extclient.ModifiedByAnnotation])
+
+	// Check that an overlapping attack on the same PeerAuthentication is refused
+	otherState := PeerAuthenticationActionState{}
+	_, err = PeerAuthenticationStrictAction{}.Prepare(context.Background(), &otherState, getTestPrepareRequest(map[string]any{"scope": "namespace"}))
+	require.NoError(t, err)
+	otherState.ExecutionId = "7b1f7e7a-7c07-4d39-a0c6-cbc3cd6c1a55"
+	_, err = PeerAuthenticationStrictAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+	policies, err := clientset.SecurityV1().PeerAuthentications("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, policies.Items, 1)
+
+	// Stop call
+	_, err = PeerAuthenticationStrictAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the exact original spec was restored
+	pa, err = clientset.SecurityV1().PeerAuthentications("shop").Get(context.Background(), "default", v1.GetOptions{})
+	require.NoError(t, err)
+	require.True(t, proto.Equal(originalSpec, &pa.Spec), "got %v", &pa.Spec)
+	require.Empty(t, pa.Annotations)
+}
@@ -0,0 +1,47 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+)
+
+// WorkloadActionState is the common state of the security policy attacks, which act on the workload selected by the
+// targeted Kubernetes service.
+type WorkloadActionState struct {
+	ExecutionId    string
+	Namespace      string
+	Name           string
+	WorkloadLabels map[string]string
+}
+
+func getTargetSelection() *action_kit_api.TargetSelection {
+	return new(action_kit_api.TargetSelection{
+		TargetType: ServiceTargetID,
+		SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+			{
+				Label: "name",
+				Query: "k8s.service.name=\"\"",
+			},
+		}),
+	})
+}
+
+// prepareWorkload resolves the targeted Kubernetes service to the labels of the workload it selects. Security policies
+// select workloads through these labels.
+func prepareWorkload(state *WorkloadActionState, request action_kit_api.PrepareActionRequestBody) error {
+	state.ExecutionId = request.ExecutionId.String()
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["k8s.service.name"][0]
+
+	workloads := extclient.Istio.GetWorkloads(state.Namespace, []string{state.Name})
+	if len(workloads) == 0 {
+		return extension_kit.ToError(fmt.Sprintf("Failed to find the workload behind Kubernetes service %s in namespace %s, the service might have no selector.", state.Name, state.Namespace), nil)
+	}
+	state.WorkloadLabels = workloads[0].Labels
+	return nil
+}
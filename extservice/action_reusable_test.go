@@ -0,0 +1,32 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func getTestPrepareRequest(config map[string]any) action_kit_api.PrepareActionRequestBody {
+	return extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":    {"shop"},
+				"k8s.service.name": {"reviews"},
+			},
+		},
+		Config: config,
+	})
+}
+
+func getReviewsService() *corev1.Service {
+	return &corev1.Service{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "shop"},
+		Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "reviews"}},
+	}
+}
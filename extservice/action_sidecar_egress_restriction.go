@@ -0,0 +1,449 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"slices"
+	"strings"
+)
+
+const (
+	sidecarScopeNamespace = "namespace"
+	sidecarScopeWorkload  = "workload"
+
+	sidecarModeExcludeHost  = "excludeHost"
+	sidecarModeRegistryOnly = "registryOnly"
+
+	// sidecarRootNamespace is Istio's default root namespace, its Sidecar without workload selector applies to all
+	// namespaces without a Sidecar of their own.
+	sidecarRootNamespace = "istio-system"
+)
+
+// SidecarActionState describes the Sidecar restricting the egress. Created tells whether the extension created the
+// resource, otherwise the original egress settings of the existing resource are restored on stop.
+type SidecarActionState struct {
+	WorkloadActionState
+	Scope string
+	Mode  string
+	// Host is the fully qualified host of the dependency excluded from the egress for the mode excludeHost.
+	Host                          string
+	SidecarName                   string
+	Created                       bool
+	Applied                       bool
+	OriginalEgress                []*networkingv1.IstioEgressListener
+	OriginalOutboundTrafficPolicy *networkingv1.OutboundTrafficPolicy
+}
+
+type SidecarEgressRestrictionAction struct {
+}
+
+func NewSidecarEgressRestrictionAction() action_kit_sdk.Action[SidecarActionState] {
+	return SidecarEgressRestrictionAction{}
+}
+
+var _ action_kit_sdk.Action[SidecarActionState] = (*SidecarEgressRestrictionAction)(nil)
+var _ action_kit_sdk.ActionWithStop[SidecarActionState] = (*SidecarEgressRestrictionAction)(nil)
+
+func (f SidecarEgressRestrictionAction) NewEmptyState() SidecarActionState {
+	return SidecarActionState{}
+}
+
+func (f SidecarEgressRestrictionAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.sidecar-egress-restriction", ServiceTargetID),
+		Label:           "Restrict Sidecar Egress",
+		Description:     "Restricts the egress of the workload behind the targeted Kubernetes service, or of its namespace, through a Sidecar resource with the outbound traffic policy REGISTRY_ONLY. Either a dependency is additionally excluded from the egress hosts, or only the traffic to hosts outside the service registry is blocked, like with a missing egress configuration.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the egress should be restricted."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "scope",
+				Label:        "Scope",
+				Description:  new("Whether the egress is restricted for the namespace of the workload, or for the workload only."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(sidecarScopeWorkload),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Workload",
+						Value: sidecarScopeWorkload,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Namespace",
+						Value: sidecarScopeNamespace,
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+			{
+				Name:         "mode",
+				Label:        "Mode",
+				Description:  new("How the egress should be restricted."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(sidecarModeExcludeHost),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Exclude a dependency from the egress hosts",
+						Value: sidecarModeExcludeHost,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Allow only hosts of the service registry (REGISTRY_ONLY)",
+						Value: sidecarModeRegistryOnly,
+					},
+				}),
+				Required: new(true),
+				Order:    new(2),
+			},
+			{
+				Name:        "host",
+				Label:       "Dependency host",
+				Description: new("Host of the dependency excluded from the egress hosts, e.g. 'ratings.backend.svc.cluster.local' or the host of a service entry. Short names are resolved relative to the namespace of the targeted service. Only used for the mode 'Exclude a dependency from the egress hosts'."),
+				Type:        action_kit_api.ActionParameterTypeString,
+				Required:    new(false),
+				Order:       new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f SidecarEgressRestrictionAction) Prepare(_ context.Context, state *SidecarActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.Scope = extutil.ToString(request.Config["scope"])
+	state.Mode = extutil.ToString(request.Config["mode"])
+
+	if state.Scope != sidecarScopeNamespace && state.Scope != sidecarScopeWorkload {
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("unknown scope '%s'", state.Scope))
+	}
+	if err := prepareWorkload(&state.WorkloadActionState, request); err != nil {
+		return nil, err
+	}
+
+	switch state.Mode {
+	case sidecarModeExcludeHost:
+		host := extutil.ToString(request.Config["host"])
+		if host == "" {
+			return nil, extension_kit.ToError("Failed prepare attack", errors.New("dependency host is required"))
+		}
+		state.Host = extclient.ToFullyQualifiedHost(state.Namespace, host)
+		if len(state.getDependencyEgressHosts(extclient.Istio.GetEgressHosts())) == 0 {
+			return nil, extension_kit.ToError(fmt.Sprintf("Failed to find the dependency host %s in the service registry, traffic to it is blocked by REGISTRY_ONLY already.", state.Host), nil)
+		}
+	case sidecarModeRegistryOnly:
+	default:
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("unknown mode '%s'", state.Mode))
+	}
+	return nil, nil
+}
+
+func (f SidecarEgressRestrictionAction) Start(ctx context.Context, state *SidecarActionState) (*action_kit_api.StartResult, error) {
+	message, err := restrictEgress(ctx, state)
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to restrict the egress in namespace %s through Kubernetes API.", state.Namespace), err)
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &[]action_kit_api.Message{
+			{
+				Level:   new(action_kit_api.Info),
+				Message: message,
+			},
+		},
+	}, nil
+}
+
+func (f SidecarEgressRestrictionAction) Stop(ctx context.Context, state *SidecarActionState) (*action_kit_api.StopResult, error) {
+	var err error
+	switch {
+	case state.Created:
+		err = extclient.Istio.DeleteSidecar(ctx, state.Namespace, state.SidecarName)
+	case state.Applied:
+		err = extclient.Istio.UpdateSidecar(ctx, state.Namespace, state.SidecarName, func(sidecar *apinetworkingv1.Sidecar) error {
+			extclient.UnmarkModified(sidecar, state.ExecutionId)
+			sidecar.Spec.Egress = cloneEgressListeners(state.OriginalEgress)
+			sidecar.Spec.OutboundTrafficPolicy = state.OriginalOutboundTrafficPolicy.DeepCopy()
+			return nil
+		})
+	}
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to restore Sidecar %s in namespace %s through Kubernetes API.", state.SidecarName, state.Namespace), err)
+	}
+	return nil, nil
+}
+
+// restrictEgress applies the restriction to the existing Sidecar for the workload or namespace, or creates one if there
+// is none. The created Sidecar starts from the egress in effect so far, as it takes precedence over the defaults of the
+// namespace and the mesh. Sidecars are marked as modified, to refuse overlapping attacks on them.
+func restrictEgress(ctx context.Context, state *SidecarActionState) (string, error) {
+	existing, err := findSidecar(ctx, state)
+	if err != nil {
+		return "", err
+	}
+
+	if existing == nil {
+		effective, err := findEffectiveSidecar(ctx, state)
+		if err != nil {
+			return "", err
+		}
+		state.SidecarName = fmt.Sprintf("steadybit-%s", state.ExecutionId)
+		sidecar := &apinetworkingv1.Sidecar{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      state.SidecarName,
+				Namespace: state.Namespace,
+				Labels: map[string]string{
+					extclient.ManagedByLabel:   extclient.ManagedByValue,
+					extclient.ExecutionIdLabel: state.ExecutionId,
+				},
+				Annotations: map[string]string{
+					extclient.ModifiedByAnnotation: state.ExecutionId,
+				},
+			},
+		}
+		if labels := state.selectorLabels(); len(labels) > 0 {
+			sidecar.Spec.WorkloadSelector = &networkingv1.WorkloadSelector{Labels: labels}
+		}
+		if effective != nil {
+			sidecar.Spec.Egress = cloneEgressListeners(effective.Spec.Egress)
+		}
+		if err = state.restrict(&sidecar.Spec); err != nil {
+			return "", err
+		}
+		if err = extclient.Istio.CreateSidecar(ctx, sidecar); err != nil {
+			return "", err
+		}
+		state.Created = true
+		return fmt.Sprintf("Created Sidecar %s in namespace %s %s%s.", state.SidecarName, state.Namespace, state.describeRestriction(), state.describeWorkloads()), nil
+	}
+
+	state.SidecarName = existing.Name
+	err = extclient.Istio.UpdateSidecar(ctx, state.Namespace, state.SidecarName, func(sidecar *apinetworkingv1.Sidecar) error {
+		if err := extclient.MarkModified(sidecar, state.ExecutionId); err != nil {
+			return err
+		}
+		state.OriginalEgress = cloneEgressListeners(sidecar.Spec.Egress)
+		state.OriginalOutboundTrafficPolicy = sidecar.Spec.OutboundTrafficPolicy.DeepCopy()
+		return state.restrict(&sidecar.Spec)
+	})
+	if err != nil {
+		return "", err
+	}
+	state.Applied = true
+	return fmt.Sprintf("Modified Sidecar %s in namespace %s %s%s.", state.SidecarName, state.Namespace, state.describeRestriction(), state.describeWorkloads()), nil
+}
+
+// restrict applies the restriction of the attack to the Sidecar. Both modes need REGISTRY_ONLY, as with ALLOW_ANY the
+// traffic to a host missing in the egress hosts is still passed through.
+func (state *SidecarActionState) restrict(spec *networkingv1.Sidecar) error {
+	switch state.Mode {
+	case sidecarModeExcludeHost:
+		if err := state.excludeHost(spec); err != nil {
+			return err
+		}
+	case sidecarModeRegistryOnly:
+	default:
+		return fmt.Errorf("unknown mode '%s'", state.Mode)
+	}
+	spec.OutboundTrafficPolicy = &networkingv1.OutboundTrafficPolicy{Mode: networkingv1.OutboundTrafficPolicy_REGISTRY_ONLY}
+	return nil
+}
+
+// excludeHost removes the dependency from the egress hosts of each listener and keeps all other egress hosts. As hosts
+// cannot be excluded from a wildcard, egress hosts like ./* covering the dependency are replaced by the registry hosts
+// they cover, except the dependency. Without egress listeners, Istio allows all hosts, i.e. */*.
+func (state *SidecarActionState) excludeHost(spec *networkingv1.Sidecar) error {
+	registryHosts := extclient.Istio.GetEgressHosts()
+	dependencyHosts := state.getDependencyEgressHosts(registryHosts)
+	if len(spec.Egress) == 0 {
+		spec.Egress = []*networkingv1.IstioEgressListener{{Hosts: []string{"*/*"}}}
+	}
+
+	removed := false
+	for _, listener := range spec.Egress {
+		var hosts []string
+		for _, egressHost := range listener.Hosts {
+			if !slices.ContainsFunc(dependencyHosts, func(dependencyHost string) bool {
+				return state.egressHostCovers(egressHost, dependencyHost)
+			}) {
+				hosts = extclient.AppendDistinct(hosts, egressHost)
+				continue
+			}
+			removed = true
+			for _, registryHost := range registryHosts {
+				if !slices.Contains(dependencyHosts, registryHost) && state.egressHostCovers(egressHost, registryHost) {
+					hosts = extclient.AppendDistinct(hosts, registryHost)
+				}
+			}
+		}
+		if len(hosts) == 0 {
+			return fmt.Errorf("no egress hosts remain after excluding %s", state.Host)
+		}
+		listener.Hosts = hosts
+	}
+
+	if !removed {
+		return fmt.Errorf("%s is not part of the egress hosts, traffic to it is blocked already", state.Host)
+	}
+	return nil
+}
+
+// getDependencyEgressHosts returns the registry hosts of the dependency, there is one for each namespace exporting it.
+func (state *SidecarActionState) getDependencyEgressHosts(registryHosts []string) []string {
+	var result []string
+	for _, registryHost := range registryHosts {
+		if _, host, _ := strings.Cut(registryHost, "/"); host == state.Host {
+			result = append(result, registryHost)
+		}
+	}
+	return result
+}
+
+// egressHostCovers tells whether the egress host of the Sidecar, in the format namespace/host, covers the registry
+// host. The namespace . refers to the namespace of the Sidecar, * to all namespaces and ~ to none.
+func (state *SidecarActionState) egressHostCovers(egressHost string, registryHost string) bool {
+	namespace, host, ok := strings.Cut(egressHost, "/")
+	if !ok {
+		return false
+	}
+	registryNamespace, registryHostName, _ := strings.Cut(registryHost, "/")
+	switch namespace {
+	case "*":
+	case ".":
+		if registryNamespace != state.Namespace {
+			return false
+		}
+	default:
+		if namespace != registryNamespace {
+			return false
+		}
+	}
+	return extclient.HostMatches(host, registryHostName)
+}
+
+func (state *SidecarActionState) describeRestriction() string {
+	if state.Mode == sidecarModeExcludeHost {
+		return "excluding " + state.Host + " from the egress"
+	}
+	return "allowing only the egress to registry hosts"
+}
+
+// findSidecar returns the Sidecar Istio applies for the scope, i.e. the oldest one selecting the workload labels, or
+// the one without workload selector for the namespace.
+func findSidecar(ctx context.Context, state *SidecarActionState) (*apinetworkingv1.Sidecar, error) {
+	sidecars, err := extclient.Istio.ListSidecars(ctx, state.Namespace)
+	if err != nil {
+		return nil, err
+	}
+
+	var result *apinetworkingv1.Sidecar
+	for _, sidecar := range sidecars {
+		if !state.matches(sidecar) {
+			continue
+		}
+		if result == nil || sidecar.CreationTimestamp.Before(&result.CreationTimestamp) {
+			result = sidecar
+		}
+	}
+	return result, nil
+}
+
+// findEffectiveSidecar returns the Sidecar Istio falls back to without a Sidecar for the scope, i.e. the one of the
+// namespace for the workload scope, or else the one of the root namespace. Sidecars created by other attacks are
+// skipped, they are not part of the original configuration.
+func findEffectiveSidecar(ctx context.Context, state *SidecarActionState) (*apinetworkingv1.Sidecar, error) {
+	namespaces := []string{sidecarRootNamespace}
+	if state.Scope == sidecarScopeWorkload {
+		namespaces = []string{state.Namespace, sidecarRootNamespace}
+	}
+
+	for _, namespace := range namespaces {
+		sidecars, err := extclient.Istio.ListSidecars(ctx, namespace)
+		if err != nil {
+			return nil, err
+		}
+
+		var result *apinetworkingv1.Sidecar
+		for _, sidecar := range sidecars {
+			if sidecar.Spec.GetWorkloadSelector() != nil || sidecar.Labels[extclient.ManagedByLabel] == extclient.ManagedByValue {
+				continue
+			}
+			if result == nil || sidecar.CreationTimestamp.Before(&result.CreationTimestamp) {
+				result = sidecar
+			}
+		}
+		if result != nil {
+			return result, nil
+		}
+	}
+	return nil, nil
+}
+
+// selectorLabels returns the workload labels for the workload scope, and none for the namespace scope.
+func (state *SidecarActionState) selectorLabels() map[string]string {
+	if state.Scope == sidecarScopeWorkload {
+		return state.WorkloadLabels
+	}
+	return nil
+}
+
+func (state *SidecarActionState) matches(sidecar *apinetworkingv1.Sidecar) bool {
+	selector := sidecar.Spec.GetWorkloadSelector().GetLabels()
+	labels := state.selectorLabels()
+	if len(labels) == 0 {
+		return len(selector) == 0
+	}
+	if len(selector) == 0 {
+		return false
+	}
+	for key, value := range selector {
+		if labels[key] != value {
+			return false
+		}
+	}
+	return true
+}
+
+func (state *SidecarActionState) describeWorkloads() string {
+	labels := state.selectorLabels()
+	if len(labels) == 0 {
+		return ""
+	}
+	return " for workloads with labels " + extclient.FormatLabels(labels)
+}
+
+func cloneEgressListeners(listeners []*networkingv1.IstioEgressListener) []*networkingv1.IstioEgressListener {
+	if listeners == nil {
+		return nil
+	}
+	result := make([]*networkingv1.IstioEgressListener, len(listeners))
+	for i, listener := range listeners {
+		result[i] = listener.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,266 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_sidecarEgressRestrictionLifecycle_createsSidecar(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	clientset := prepareSidecarTest(t, stopCh)
+
+	// Prepare call
+	state := SidecarActionState{}
+	_, err := SidecarEgressRestrictionAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"scope": "workload",
+		"mode":  "excludeHost",
+		"host":  "ratings.backend.svc",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, "ratings.backend.svc.cluster.local", state.Host)
+
+	// Start call
+	_, err = SidecarEgressRestrictionAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	state = extutil.JsonMangle(state)
+
+	// Check that a Sidecar without the dependency in its egress hosts was created for the workload
+	sidecar, err := clientset.NetworkingV1().Sidecars("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, sidecar.Labels[extclient.ManagedByLabel])
+	require.Equal(t, map[string]string{"app": "reviews"}, sidecar.Spec.WorkloadSelector.Labels)
+	require.Len(t, sidecar.Spec.Egress, 1)
+	require.Equal(t, []string{"shop/details.shop.svc.cluster.local", "shop/reviews.shop.svc.cluster.local"}, sidecar.Spec.Egress[0].Hosts)
+	require.Equal(t, networkingv1.OutboundTrafficPolicy_REGISTRY_ONLY, sidecar.Spec.OutboundTrafficPolicy.Mode)
+
+	// Check that an overlapping attack does not adopt the created Sidecar
+	otherState := SidecarActionState{}
+	_, err = SidecarEgressRestrictionAction{}.Prepare(context.Background(), &otherState, getTestPrepareRequest(map[string]any{
+		"scope": "workload",
+		"mode":  "registryOnly",
+	}))
+	require.NoError(t, err)
+	otherState.ExecutionId = "7b1f7e7a-7c07-4d39-a0c6-cbc3cd6c1a55"
+	_, err = SidecarEgressRestrictionAction{}.Start(context.Background(), &otherState)
+	require.ErrorContains(t, err, "already modified by another attack")
+
+	// Stop call
+	_, err = SidecarEgressRestrictionAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the Sidecar was deleted
+	sidecars, err := clientset.NetworkingV1().Sidecars("").List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, sidecars.Items)
+}
+
+func Test_sidecarEgressRestrictionLifecycle_modifiesSidecar(t *testing.T) {
+	tests := []struct {
+		name          string
+		config        map[string]any
+		expectedHosts []string
+	}{
+		{
+			name:          "registry only",
+			config:        map[string]any{"scope": "namespace", "mode": "registryOnly"},
+			expectedHosts: []string{"./*", "backend/ratings.backend.svc.cluster.local", "istio-system/*"},
+		},
+		{
+			name:          "exclude host covered by a wildcard",
+			config:        map[string]any{"scope": "namespace", "mode": "excludeHost", "host": "details"},
+			expectedHosts: []string{"shop/reviews.shop.svc.cluster.local", "backend/ratings.backend.svc.cluster.local", "istio-system/*"},
+		},
+		{
+			name:          "exclude exact host",
+			config:        map[string]any{"scope": "namespace", "mode": "excludeHost", "host": "ratings.backend.svc.cluster.local"},
+			expectedHosts: []string{"./*", "istio-system/*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			clientset := prepareSidecarTest(t, stopCh)
+
+			originalSpec := &networkingv1.Sidecar{
+				Egress: []*networkingv1.IstioEgressListener{
+					{Hosts: []string{"./*", "backend/ratings.backend.svc.cluster.local", "istio-system/*"}},
+				},
+			}
+			_, err := clientset.NetworkingV1().Sidecars("shop").Create(context.Background(), &apinetworkingv1.Sidecar{
+				ObjectMeta: v1.ObjectMeta{Name: "default", Namespace: "shop"},
+				Spec:       *originalSpec.DeepCopy(),
+			}, v1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Prepare call
+			state := SidecarActionState{}
+			_, err = SidecarEgressRestrictionAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(tt.config))
+			require.NoError(t, err)
+
+			// Start call
+			_, err = SidecarEgressRestrictionAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			state = extutil.JsonMangle(state)
+
+			// Check that the egress of the existing namespace wide Sidecar was narrowed, not replaced
+			sidecar, err := clientset.NetworkingV1().Sidecars("shop").Get(context.Background(), "default", v1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, networkingv1.OutboundTrafficPolicy_REGISTRY_ONLY, sidecar.Spec.OutboundTrafficPolicy.Mode)
+			require.Equal(t, tt.expectedHosts, sidecar.Spec.Egress[0].Hosts)
+			require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", sidecar.Annotations[extclient.ModifiedByAnnotation])
+
+			// Stop call
+			_, err = SidecarEgressRestrictionAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that the exact original spec was restored
+			sidecar, err = clientset.NetworkingV1().Sidecars("shop").Get(context.Background(), "default", v1.GetOptions{})
+			require.NoError(t, err)
+			require.True(t, proto.Equal(originalSpec, &sidecar.Spec), "got %v", &sidecar.Spec)
+			require.Empty(t, sidecar.Annotations)
+		})
+	}
+}
+
+func Test_sidecarEgressRestrictionLifecycle_createsSidecarFromEffectiveEgress(t *testing.T) {
+	tests := []struct {
+		name          string
+		namespace     string
+		config        map[string]any
+		expectedHosts []string
+	}{
+		{
+			name:          "workload scope excluding a host of the namespace Sidecar",
+			namespace:     "shop",
+			config:        map[string]any{"scope": "workload", "mode": "excludeHost", "host": "details"},
+			expectedHosts: []string{"shop/reviews.shop.svc.cluster.local", "istio-system/*"},
+		},
+		{
+			name:          "workload scope allowing only registry hosts of the namespace Sidecar",
+			namespace:     "shop",
+			config:        map[string]any{"scope": "workload", "mode": "registryOnly"},
+			expectedHosts: []string{"./*", "istio-system/*"},
+		},
+		{
+			name:          "namespace scope excluding a host of the root namespace Sidecar",
+			namespace:     "istio-system",
+			config:        map[string]any{"scope": "namespace", "mode": "excludeHost", "host": "details"},
+			expectedHosts: []string{"shop/reviews.shop.svc.cluster.local", "istio-system/*"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			clientset := prepareSidecarTest(t, stopCh)
+
+			_, err := clientset.NetworkingV1().Sidecars(tt.namespace).Create(context.Background(), &apinetworkingv1.Sidecar{
+				ObjectMeta: v1.ObjectMeta{Name: "default", Namespace: tt.namespace},
+				Spec: networkingv1.Sidecar{
+					Egress: []*networkingv1.IstioEgressListener{{Hosts: []string{"./*", "istio-system/*"}}},
+				},
+			}, v1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Prepare call
+			state := SidecarActionState{}
+			_, err = SidecarEgressRestrictionAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(tt.config))
+			require.NoError(t, err)
+
+			// Start call
+			_, err = SidecarEgressRestrictionAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			state = extutil.JsonMangle(state)
+
+			// Check that the created Sidecar restricts the egress in effect so far instead of allowing all hosts
+			sidecar, err := clientset.NetworkingV1().Sidecars("shop").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060", v1.GetOptions{})
+			require.NoError(t, err)
+			require.Len(t, sidecar.Spec.Egress, 1)
+			require.Equal(t, tt.expectedHosts, sidecar.Spec.Egress[0].Hosts)
+			require.Equal(t, networkingv1.OutboundTrafficPolicy_REGISTRY_ONLY, sidecar.Spec.OutboundTrafficPolicy.Mode)
+
+			// Stop call
+			_, err = SidecarEgressRestrictionAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that only the created Sidecar was deleted
+			sidecars, err := clientset.NetworkingV1().Sidecars("").List(context.Background(), v1.ListOptions{})
+			require.NoError(t, err)
+			require.Len(t, sidecars.Items, 1)
+			require.Equal(t, "default", sidecars.Items[0].Name)
+		})
+	}
+}
+
+func Test_sidecarEgressRestrictionFailsForHostOutsideEgress(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	clientset := prepareSidecarTest(t, stopCh)
+
+	_, err := clientset.NetworkingV1().Sidecars("shop").Create(context.Background(), &apinetworkingv1.Sidecar{
+		ObjectMeta: v1.ObjectMeta{Name: "default", Namespace: "shop"},
+		Spec: networkingv1.Sidecar{
+			Egress: []*networkingv1.IstioEgressListener{{Hosts: []string{"./*", "istio-system/*"}}},
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	state := SidecarActionState{}
+	_, err = SidecarEgressRestrictionAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"scope": "namespace",
+		"mode":  "excludeHost",
+		"host":  "ratings.backend.svc.cluster.local",
+	}))
+	require.NoError(t, err)
+
+	_, err = SidecarEgressRestrictionAction{}.Start(context.Background(), &state)
+	require.ErrorContains(t, err, "Failed to restrict the egress")
+}
+
+func Test_sidecarEgressRestrictionKeepsExternalHosts(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	prepareSidecarTest(t, stopCh)
+
+	state := SidecarActionState{}
+	_, err := SidecarEgressRestrictionAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"scope": "workload",
+		"mode":  "excludeHost",
+		"host":  "httpbin.org",
+	}))
+	require.ErrorContains(t, err, "Failed to find the dependency host httpbin.org in the service registry")
+	require.Equal(t, "httpbin.org", state.Host)
+}
+
+func prepareSidecarTest(t *testing.T, stopCh <-chan struct{}) versionedClient.Interface {
+	client, clientset := getTestClient(t, stopCh,
+		getReviewsService(),
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "details", Namespace: "shop"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "details"}},
+		},
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "ratings", Namespace: "backend"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "ratings"}},
+		},
+	)
+	extclient.Istio = client
+	return clientset
+}
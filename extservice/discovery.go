@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-kit/extbuild"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const discoveryBasePath = basePath + "/discovery"
+
+type serviceDiscovery struct {
+}
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*serviceDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*serviceDiscovery)(nil)
+)
+
+func NewServiceDiscovery() discovery_kit_sdk.TargetDiscovery {
+	discovery := &serviceDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 30*time.Second),
+	)
+}
+
+func (d *serviceDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: ServiceTargetID,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			Method:       "GET",
+			Path:         discoveryBasePath + "/discovered-targets",
+			CallInterval: new("30s"),
+		},
+	}
+}
+
+func (d *serviceDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       ServiceTargetID,
+		Icon:     new(targetIcon),
+		Label:    discovery_kit_api.PluralLabel{One: "Mesh Service", Other: "Mesh Services"},
+		Category: new("Kubernetes"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "k8s.service.name"},
+				{Attribute: "istio.kubernetes-service.virtual-service"},
+				{Attribute: "k8s.namespace"},
+				{Attribute: "k8s.cluster-name"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "k8s.service.name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *serviceDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "istio.kubernetes-service.host",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kubernetes Service host",
+				Other: "Kubernetes Service hosts",
+			},
+		},
+		{
+			Attribute: "istio.kubernetes-service.port",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Kubernetes Service port",
+				Other: "Kubernetes Service ports",
+			},
+		},
+		{
+			Attribute: "istio.kubernetes-service.virtual-service",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Virtual Service",
+				Other: "Virtual Services",
+			},
+		},
+	}
+}
+
+func (d *serviceDiscovery) DiscoverTargets(_ context.Context) ([]discovery_kit_api.Target, error) {
+	return getServiceTargets(extclient.Istio), nil
+}
+
+func getServiceTargets(client *extclient.IstioClient) []discovery_kit_api.Target {
+	services := client.GetServices()
+	result := make([]discovery_kit_api.Target, len(services))
+
+	for i, service := range services {
+		host := extclient.ToFullyQualifiedHost(service.Namespace, service.Name)
+
+		attributes := make(map[string][]string)
+		attributes["k8s.service.name"] = []string{service.Name}
+		attributes["istio.kubernetes-service.host"] = []string{host}
+		attributes["k8s.namespace"] = []string{service.Namespace}
+		attributes["k8s.cluster-name"] = []string{extconfig.Config.ClusterName}
+
+		for _, port := range service.Spec.Ports {
+			attributes["istio.kubernetes-service.port"] = append(attributes["istio.kubernetes-service.port"], strconv.Itoa(int(port.Port)))
+		}
+		for _, virtualService := range client.GetVirtualServicesForHost(host) {
+			attributes["istio.kubernetes-service.virtual-service"] = append(attributes["istio.kubernetes-service.virtual-service"], virtualService.Namespace+"/"+virtualService.Name)
+		}
+		slices.Sort(attributes["istio.kubernetes-service.virtual-service"])
+
+		for key, value := range service.Labels {
+			attributes["k8s.service.label."+key] = []string{value}
+		}
+
+		result[i] = discovery_kit_api.Target{
+			Id:         fmt.Sprintf("%s/%s/%s", extconfig.Config.ClusterName, service.Namespace, service.Name),
+			Label:      service.Name,
+			TargetType: ServiceTargetID,
+			Attributes: attributes,
+		}
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(result, extconfig.Config.DiscoveryAttributesExcludesService)
+}
@@ -0,0 +1,89 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extservice
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	testclient "istio.io/client-go/pkg/clientset/versioned/fake"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/features"
+	clientfeaturestesting "k8s.io/client-go/features/testing"
+	k8stestclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func Test_getDiscoveredServices(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh,
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "reviews",
+				Namespace: "shop",
+				Labels: map[string]string{
+					"best-city": "Kevelaer",
+					"toIgnore":  "Bielefeld",
+				},
+			},
+			Spec: corev1.ServiceSpec{
+				Selector: map[string]string{"app": "reviews"},
+				Ports:    []corev1.ServicePort{{Port: 8080}, {Port: 9090}},
+			},
+		},
+	)
+	extconfig.Config.ClusterName = "development"
+	extconfig.Config.DiscoveryAttributesExcludesService = []string{"k8s.service.label.toIgnore"}
+
+	_, err := clientset.
+		NetworkingV1().
+		VirtualServices("shop").
+		Create(context.Background(), &apiv1.VirtualService{
+			ObjectMeta: v1.ObjectMeta{Name: "reviews-route", Namespace: "shop"},
+			Spec:       networkingv1.VirtualService{Hosts: []string{"reviews"}},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then
+	targets := getServiceTargets(client)
+	require.Len(t, targets, 1)
+	target := targets[0]
+	require.Equal(t, "development/shop/reviews", target.Id)
+	require.Equal(t, ServiceTargetID, target.TargetType)
+	require.Equal(t, "reviews", target.Label)
+	require.Equal(t, map[string][]string{
+		"k8s.service.name":                         {"reviews"},
+		"istio.kubernetes-service.host":            {"reviews.shop.svc.cluster.local"},
+		"istio.kubernetes-service.port":            {"8080", "9090"},
+		"istio.kubernetes-service.virtual-service": {"shop/reviews-route"},
+		"k8s.namespace":                            {"shop"},
+		"k8s.cluster-name":                         {"development"},
+		"k8s.service.label.best-city":              {"Kevelaer"},
+	}, target.Attributes)
+}
+
+func getTestClient(t testing.TB, stopCh <-chan struct{}, kubernetesObjects ...runtime.Object) (*extclient.IstioClient, versionedClient.Interface) {
+	// Disable WatchListClient feature gate: the fake client doesn't support
+	// the bookmark events required by the WatchList stream, causing informers
+	// to hang indefinitely.
+	clientfeaturestesting.SetFeatureDuringTest(t, features.WatchListClient, false)
+	clientset := testclient.NewSimpleClientset()
+	client := extclient.NewIstioClient(clientset, k8stestclient.NewSimpleClientset(kubernetesObjects...), stopCh)
+	return client, clientset
+}
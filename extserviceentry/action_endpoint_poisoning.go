@@ -0,0 +1,222 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	"net/netip"
+	"slices"
+	"strings"
+)
+
+const (
+	endpointPoisoningModeStatic = "static"
+	endpointPoisoningModeDNS    = "dns"
+)
+
+type EndpointPoisoningActionState struct {
+	ExecutionId string
+	Namespace   string
+	Name        string
+	Mode        string
+	// Addresses are the static endpoint addresses for the mode static, Hostname the endpoint resolved for the mode dns.
+	Addresses []string
+	Hostname  string
+	// Applied tells whether the attack modified the ServiceEntry, i.e. whether the original endpoints must be restored.
+	Applied                  bool
+	OriginalResolution       networkingv1.ServiceEntry_Resolution
+	OriginalEndpoints        []*networkingv1.WorkloadEntry
+	OriginalWorkloadSelector *networkingv1.WorkloadSelector
+}
+
+type EndpointPoisoningAction struct {
+}
+
+func NewEndpointPoisoningAction() action_kit_sdk.Action[EndpointPoisoningActionState] {
+	return EndpointPoisoningAction{}
+}
+
+var _ action_kit_sdk.Action[EndpointPoisoningActionState] = (*EndpointPoisoningAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EndpointPoisoningActionState] = (*EndpointPoisoningAction)(nil)
+
+func (f EndpointPoisoningAction) NewEmptyState() EndpointPoisoningActionState {
+	return EndpointPoisoningActionState{}
+}
+
+func (f EndpointPoisoningAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:              fmt.Sprintf("%s.endpoint-poisoning", ServiceEntryTargetID),
+		Label:           "Poison Service Entry Endpoints",
+		Description:     "Replaces the endpoints of the targeted service entries with unroutable addresses or a hostname which cannot be resolved, emulating IP changes or DNS failures of external dependencies on the network level.",
+		Version:         extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:            new(targetIcon),
+		TargetSelection: getTargetSelection(),
+		Technology:      new("Istio"),
+		Kind:            action_kit_api.Attack,
+		TimeControl:     action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the endpoints should be poisoned."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "mode",
+				Label:        "Mode",
+				Description:  new("How the endpoints should be poisoned."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(endpointPoisoningModeStatic),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Static unroutable addresses",
+						Value: endpointPoisoningModeStatic,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "DNS resolution of a bogus hostname",
+						Value: endpointPoisoningModeDNS,
+					},
+				}),
+				Required: new(true),
+				Order:    new(1),
+			},
+			{
+				Name:         "addresses",
+				Label:        "Addresses",
+				Description:  new("IP addresses used as static endpoints. The default is taken from a documentation range and is not routable. Only used for the mode 'Static unroutable addresses'."),
+				Type:         action_kit_api.ActionParameterTypeStringArray,
+				DefaultValue: new("[\"192.0.2.1\"]"),
+				Required:     new(false),
+				Order:        new(2),
+			},
+			{
+				Name:         "hostname",
+				Label:        "Hostname",
+				Description:  new("Hostname resolved through DNS as endpoint. Only used for the mode 'DNS resolution of a bogus hostname'."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new("nonexistent.steadybit.invalid"),
+				Required:     new(false),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f EndpointPoisoningAction) Prepare(_ context.Context, state *EndpointPoisoningActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	state.ExecutionId = request.ExecutionId.String()
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["istio.service-entry.name"][0]
+	state.Mode = extutil.ToString(request.Config["mode"])
+
+	switch state.Mode {
+	case endpointPoisoningModeStatic:
+		state.Addresses = extutil.ToStringArray(request.Config["addresses"])
+		if len(state.Addresses) == 0 {
+			return nil, extension_kit.ToError("Failed prepare attack", errors.New("at least one address is required"))
+		}
+		for _, address := range state.Addresses {
+			if _, err := netip.ParseAddr(address); err != nil {
+				return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("invalid address '%s'", address))
+			}
+		}
+	case endpointPoisoningModeDNS:
+		state.Hostname = extutil.ToString(request.Config["hostname"])
+		if state.Hostname == "" {
+			return nil, extension_kit.ToError("Failed prepare attack", errors.New("hostname is required"))
+		}
+	default:
+		return nil, extension_kit.ToError("Failed prepare attack", fmt.Errorf("unknown mode '%s'", state.Mode))
+	}
+	return nil, nil
+}
+
+// Start snapshots the resolution and endpoints of the ServiceEntry into the state before poisoning them, so that they
+// can be restored exactly on stop. The ServiceEntry is marked as modified, to refuse overlapping attacks on it.
+func (f EndpointPoisoningAction) Start(ctx context.Context, state *EndpointPoisoningActionState) (*action_kit_api.StartResult, error) {
+	err := extclient.Istio.UpdateServiceEntry(ctx, state.Namespace, state.Name, func(se *apinetworkingv1.ServiceEntry) error {
+		if err := extclient.MarkModified(se, state.ExecutionId); err != nil {
+			return err
+		}
+		state.OriginalResolution = se.Spec.Resolution
+		state.OriginalEndpoints = cloneEndpoints(se.Spec.Endpoints)
+		state.OriginalWorkloadSelector = se.Spec.WorkloadSelector.DeepCopy()
+		return poisonEndpoints(state, &se.Spec)
+	})
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to modify ServiceEntry %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = true
+	return nil, nil
+}
+
+func (f EndpointPoisoningAction) Stop(ctx context.Context, state *EndpointPoisoningActionState) (*action_kit_api.StopResult, error) {
+	if !state.Applied {
+		return nil, nil
+	}
+
+	err := extclient.Istio.UpdateServiceEntry(ctx, state.Namespace, state.Name, func(se *apinetworkingv1.ServiceEntry) error {
+		se.Spec.Resolution = state.OriginalResolution
+		se.Spec.Endpoints = cloneEndpoints(state.OriginalEndpoints)
+		se.Spec.WorkloadSelector = state.OriginalWorkloadSelector.DeepCopy()
+		extclient.UnmarkModified(se, state.ExecutionId)
+		return nil
+	})
+	if err != nil {
+		return nil, extension_kit.ToError(fmt.Sprintf("Failed to restore ServiceEntry %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	state.Applied = false
+	return nil, nil
+}
+
+// poisonEndpoints replaces the endpoints of the ServiceEntry. The workload selector is dropped, as Istio doesn't allow
+// it together with endpoints.
+func poisonEndpoints(state *EndpointPoisoningActionState, spec *networkingv1.ServiceEntry) error {
+	spec.WorkloadSelector = nil
+
+	switch state.Mode {
+	case endpointPoisoningModeStatic:
+		spec.Resolution = networkingv1.ServiceEntry_STATIC
+		spec.Endpoints = make([]*networkingv1.WorkloadEntry, len(state.Addresses))
+		for i, address := range state.Addresses {
+			spec.Endpoints[i] = &networkingv1.WorkloadEntry{Address: address}
+		}
+	case endpointPoisoningModeDNS:
+		// DNS resolution is not supported for wildcard hosts
+		if slices.ContainsFunc(spec.Hosts, func(host string) bool { return strings.HasPrefix(host, "*") }) {
+			return errors.New("DNS resolution is not supported for ServiceEntries with wildcard hosts")
+		}
+		spec.Resolution = networkingv1.ServiceEntry_DNS
+		spec.Endpoints = []*networkingv1.WorkloadEntry{{Address: state.Hostname}}
+	default:
+		return fmt.Errorf("unknown mode '%s'", state.Mode)
+	}
+	return nil
+}
+
+func cloneEndpoints(endpoints []*networkingv1.WorkloadEntry) []*networkingv1.WorkloadEntry {
+	if endpoints == nil {
+		return nil
+	}
+	result := make([]*networkingv1.WorkloadEntry, len(endpoints))
+	for i, endpoint := range endpoints {
+		result[i] = endpoint.DeepCopy()
+	}
+	return result
+}
@@ -0,0 +1,133 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_endpointPoisoningLifecycle(t *testing.T) {
+	tests := []struct {
+		name               string
+		config             map[string]any
+		expectedResolution networkingv1.ServiceEntry_Resolution
+		expectedEndpoints  []*networkingv1.WorkloadEntry
+	}{
+		{
+			name:               "static",
+			config:             map[string]any{"mode": "static", "addresses": []any{"192.0.2.1", "192.0.2.2"}},
+			expectedResolution: networkingv1.ServiceEntry_STATIC,
+			expectedEndpoints:  []*networkingv1.WorkloadEntry{{Address: "192.0.2.1"}, {Address: "192.0.2.2"}},
+		},
+		{
+			name:               "dns",
+			config:             map[string]any{"mode": "dns", "hostname": "nonexistent.steadybit.invalid"},
+			expectedResolution: networkingv1.ServiceEntry_DNS,
+			expectedEndpoints:  []*networkingv1.WorkloadEntry{{Address: "nonexistent.steadybit.invalid"}},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			// General preparation
+			stopCh := make(chan struct{})
+			defer close(stopCh)
+			client, clientset := getTestClient(t, stopCh)
+			extclient.Istio = client
+
+			originalSpec := &networkingv1.ServiceEntry{
+				Hosts:      []string{"api.stripe.com"},
+				Ports:      []*networkingv1.ServicePort{{Number: 443, Name: "https", Protocol: "HTTPS"}},
+				Location:   networkingv1.ServiceEntry_MESH_EXTERNAL,
+				Resolution: networkingv1.ServiceEntry_STATIC,
+				Endpoints:  []*networkingv1.WorkloadEntry{{Address: "10.0.0.1", Labels: map[string]string{"zone": "a"}}},
+			}
+			_, err := clientset.NetworkingV1().ServiceEntries("payment").Create(context.Background(), &apiv1.ServiceEntry{
+				ObjectMeta: v1.ObjectMeta{Name: "stripe", Namespace: "payment"},
+				Spec:       *originalSpec.DeepCopy(),
+			}, v1.CreateOptions{})
+			require.NoError(t, err)
+
+			// Prepare call
+			state := EndpointPoisoningActionState{}
+			_, err = EndpointPoisoningAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(tt.config))
+			require.NoError(t, err)
+			state = extutil.JsonMangle(state)
+
+			// Start call
+			_, err = EndpointPoisoningAction{}.Start(context.Background(), &state)
+			require.NoError(t, err)
+			state = extutil.JsonMangle(state)
+
+			// Check that the endpoints were poisoned
+			se, err := clientset.NetworkingV1().ServiceEntries("payment").Get(context.Background(), "stripe", v1.GetOptions{})
+			require.NoError(t, err)
+			require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", se.Annotations[extclient.ModifiedByAnnotation])
+			require.Equal(t, tt.expectedResolution, se.Spec.Resolution)
+			require.Len(t, se.Spec.Endpoints, len(tt.expectedEndpoints))
+			for i, endpoint := range tt.expectedEndpoints {
+				require.True(t, proto.Equal(endpoint, se.Spec.Endpoints[i]), "got %v", se.Spec.Endpoints[i])
+			}
+
+			// Stop call
+			_, err = EndpointPoisoningAction{}.Stop(context.Background(), &state)
+			require.NoError(t, err)
+
+			// Check that the exact original spec was restored
+			se, err = clientset.NetworkingV1().ServiceEntries("payment").Get(context.Background(), "stripe", v1.GetOptions{})
+			require.NoError(t, err)
+			require.True(t, proto.Equal(originalSpec, &se.Spec), "got %v", &se.Spec)
+			require.Empty(t, se.Annotations)
+		})
+	}
+}
+
+func Test_endpointPoisoningRejectsInvalidAddresses(t *testing.T) {
+	state := EndpointPoisoningActionState{}
+	_, err := EndpointPoisoningAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"mode":      "static",
+		"addresses": []any{"not-an-ip"},
+	}))
+	require.ErrorContains(t, err, "invalid address 'not-an-ip'")
+}
+
+func Test_endpointPoisoningRefusesServiceEntryModifiedByAnotherAttack(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+	_, err := clientset.NetworkingV1().ServiceEntries("payment").Create(context.Background(), &apiv1.ServiceEntry{
+		ObjectMeta: v1.ObjectMeta{
+			Name:        "stripe",
+			Namespace:   "payment",
+			Annotations: map[string]string{extclient.ModifiedByAnnotation: "e5b4a0a4-2b8f-4b59-8f7e-1a8f4c3d9a11"},
+		},
+		Spec: networkingv1.ServiceEntry{
+			Hosts:      []string{"api.stripe.com"},
+			Resolution: networkingv1.ServiceEntry_DNS,
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	state := EndpointPoisoningActionState{}
+	_, err = EndpointPoisoningAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"mode":     "dns",
+		"hostname": "nonexistent.steadybit.invalid",
+	}))
+	require.NoError(t, err)
+
+	// When
+	_, err = EndpointPoisoningAction{}.Start(context.Background(), &state)
+
+	// Then
+	require.ErrorContains(t, err, "already modified by another attack")
+	require.False(t, state.Applied)
+}
@@ -0,0 +1,57 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extvirtualservice"
+	extension_kit "github.com/steadybit/extension-kit"
+	networkingv1 "istio.io/api/networking/v1"
+	"strings"
+)
+
+func getTargetSelection() *action_kit_api.TargetSelection {
+	return new(action_kit_api.TargetSelection{
+		TargetType: ServiceEntryTargetID,
+		SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+			{
+				Label: "name",
+				Query: "istio.service-entry.name=\"\"",
+			},
+		}),
+	})
+}
+
+// prepareServiceEntryFault injects the fault into the traffic to all hosts of the ServiceEntry. Wildcard hosts are
+// skipped, as a VirtualService for them would capture the traffic to other hosts too. Hosts are qualified the same
+// way as the hosts of VirtualServices to find the VirtualServices already routing them.
+func prepareServiceEntryFault(ctx context.Context,
+	state *extvirtualservice.HostFaultActionState,
+	request action_kit_api.PrepareActionRequestBody,
+	toFault func(req action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection) error {
+
+	namespace := request.Target.Attributes["k8s.namespace"][0]
+	name := request.Target.Attributes["istio.service-entry.name"][0]
+
+	serviceEntry, err := extclient.Istio.GetServiceEntry(ctx, namespace, name)
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to fetch ServiceEntry %s in namespace %s through Kubernetes API.", name, namespace), err)
+	}
+
+	var hosts []string
+	for _, host := range serviceEntry.Spec.Hosts {
+		if strings.HasPrefix(host, "*") {
+			continue
+		}
+		hosts = append(hosts, extclient.ToFullyQualifiedHost(namespace, host))
+	}
+	if len(hosts) == 0 {
+		return extension_kit.ToError(fmt.Sprintf("ServiceEntry %s in namespace %s has only wildcard hosts, HTTP faults cannot be injected.", name, namespace), nil)
+	}
+
+	return extvirtualservice.PrepareHostFault(state, request, namespace, hosts, toFault)
+}
@@ -0,0 +1,278 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extvirtualservice"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/proto"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	k8serrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+	"time"
+)
+
+func Test_httpFaultLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	createServiceEntry(t, clientset, []string{"api.stripe.com", "files.stripe.com", "*.internal.stripe.com"})
+	existingSpec := &networkingv1.VirtualService{
+		Hosts: []string{"files.stripe.com"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "files", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "files.stripe.com"}}}},
+		},
+	}
+	_, err := clientset.NetworkingV1().VirtualServices("payment").Create(context.Background(), &apiv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{Name: "stripe-files", Namespace: "payment"},
+		Spec:       *existingSpec.DeepCopy(),
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err = HttpAbortAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"statusCode":       503.0,
+		"percentage":       100.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "payment", Name: "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", Host: "api.stripe.com", Temporary: true},
+		{Namespace: "payment", Name: "stripe-files", Host: "files.stripe.com"},
+	}, state.VirtualServices)
+	state = extutil.JsonMangle(state)
+
+	// Start call
+	result, err := HttpAbortAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+	require.Len(t, *result.Messages, 2)
+
+	// Check that a temporary VirtualService was created for the host without one
+	temporary, err := clientset.NetworkingV1().VirtualServices("payment").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, extclient.ManagedByValue, temporary.Labels[extclient.ManagedByLabel])
+	require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", temporary.Labels[extclient.ExecutionIdLabel])
+	require.Equal(t, []string{"api.stripe.com"}, temporary.Spec.Hosts)
+	require.Len(t, temporary.Spec.Http, 2)
+	require.Equal(t, "steadybit-injected-fault_22955847-b455-461d-8f9b-61ef1ef05060_0", temporary.Spec.Http[0].Name)
+	require.Equal(t, int32(503), temporary.Spec.Http[0].Fault.GetAbort().GetHttpStatus())
+	require.Equal(t, "api.stripe.com", temporary.Spec.Http[0].Route[0].Destination.Host)
+	require.Nil(t, temporary.Spec.Http[1].Fault)
+	require.Equal(t, "api.stripe.com", temporary.Spec.Http[1].Route[0].Destination.Host)
+
+	// Check that the fault was added to the existing VirtualService
+	existing := getVirtualService(t, clientset, "stripe-files")
+	require.Len(t, existing.Spec.Http, 2)
+	require.Equal(t, "steadybit-injected-fault_22955847-b455-461d-8f9b-61ef1ef05060_0", existing.Spec.Http[0].Name)
+	require.NotNil(t, existing.Spec.Http[0].Fault)
+	require.Equal(t, "files", existing.Spec.Http[1].Name)
+
+	// Stop call
+	_, err = HttpAbortAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the temporary VirtualService was deleted and the existing one restored
+	_, err = clientset.NetworkingV1().VirtualServices("payment").Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0", v1.GetOptions{})
+	require.True(t, k8serrors.IsNotFound(err))
+	existing = getVirtualService(t, clientset, "stripe-files")
+	require.True(t, proto.Equal(existingSpec, &existing.Spec), "got %v", &existing.Spec)
+}
+
+func Test_httpFaultLifecycleWithWildcardVirtualService(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	createServiceEntry(t, clientset, []string{"stripe.com", "api.stripe.com", "files.stripe.com"})
+	rootSpec := &networkingv1.VirtualService{
+		Hosts: []string{"stripe.com"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "root", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "stripe.com"}}}},
+		},
+	}
+	wildcardSpec := &networkingv1.VirtualService{
+		Hosts: []string{"*.stripe.com"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "all", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "egress.stripe.com"}}}},
+		},
+	}
+	for name, spec := range map[string]*networkingv1.VirtualService{"stripe-root": rootSpec, "stripe-wildcard": wildcardSpec} {
+		_, err := clientset.NetworkingV1().VirtualServices("payment").Create(context.Background(), &apiv1.VirtualService{
+			ObjectMeta: v1.ObjectMeta{Name: name, Namespace: "payment"},
+			Spec:       *spec.DeepCopy(),
+		}, v1.CreateOptions{})
+		require.NoError(t, err)
+	}
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 2
+	}, time.Minute, 100*time.Millisecond)
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err := HttpAbortAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"statusCode":       503.0,
+		"percentage":       100.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "payment", Name: "stripe-root", Host: "stripe.com"},
+		{Namespace: "payment", Name: "stripe-wildcard", Host: "api.stripe.com", Restricted: true},
+		{Namespace: "payment", Name: "stripe-wildcard", Host: "files.stripe.com", Restricted: true},
+	}, state.VirtualServices)
+	state = extutil.JsonMangle(state)
+
+	// Start call
+	_, err = HttpAbortAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the wildcard VirtualService only faults the requests for the targeted hosts
+	wildcard := getVirtualService(t, clientset, "stripe-wildcard")
+	require.Len(t, wildcard.Spec.Http, 3)
+	require.Equal(t, `api\.stripe\.com(:[0-9]+)?`, wildcard.Spec.Http[0].Match[0].Authority.GetRegex())
+	require.NotNil(t, wildcard.Spec.Http[0].Fault)
+	require.Equal(t, `files\.stripe\.com(:[0-9]+)?`, wildcard.Spec.Http[1].Match[0].Authority.GetRegex())
+	require.NotNil(t, wildcard.Spec.Http[1].Fault)
+	require.Equal(t, "all", wildcard.Spec.Http[2].Name)
+	require.Nil(t, wildcard.Spec.Http[2].Fault)
+
+	// Stop call
+	_, err = HttpAbortAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the VirtualServices were restored
+	require.True(t, proto.Equal(rootSpec, &getVirtualService(t, clientset, "stripe-root").Spec))
+	require.True(t, proto.Equal(wildcardSpec, &getVirtualService(t, clientset, "stripe-wildcard").Spec))
+}
+
+func Test_httpFaultLifecycleWithVirtualServiceForOtherHosts(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	createServiceEntry(t, clientset, []string{"api.stripe.com"})
+	sharedSpec := &networkingv1.VirtualService{
+		Hosts: []string{"api.stripe.com", "status.stripe.com"},
+		Http: []*networkingv1.HTTPRoute{
+			{Name: "all", Route: []*networkingv1.HTTPRouteDestination{{Destination: &networkingv1.Destination{Host: "egress.stripe.com"}}}},
+		},
+	}
+	_, err := clientset.NetworkingV1().VirtualServices("payment").Create(context.Background(), &apiv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{Name: "stripe-shared", Namespace: "payment"},
+		Spec:       *sharedSpec.DeepCopy(),
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		return len(client.GetVirtualServices()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Prepare call
+	state := extvirtualservice.HostFaultActionState{}
+	_, err = HttpAbortAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"statusCode":       503.0,
+		"percentage":       100.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.NoError(t, err)
+	require.Equal(t, []extvirtualservice.HostFaultVirtualService{
+		{Namespace: "payment", Name: "stripe-shared", Host: "api.stripe.com", Restricted: true},
+	}, state.VirtualServices)
+	state = extutil.JsonMangle(state)
+
+	// Start call
+	_, err = HttpAbortAction{}.Start(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the requests for the other host of the VirtualService are not faulted
+	shared := getVirtualService(t, clientset, "stripe-shared")
+	require.Len(t, shared.Spec.Http, 2)
+	require.Equal(t, `api\.stripe\.com(:[0-9]+)?`, shared.Spec.Http[0].Match[0].Authority.GetRegex())
+	require.NotNil(t, shared.Spec.Http[0].Fault)
+	require.Equal(t, "all", shared.Spec.Http[1].Name)
+	require.Nil(t, shared.Spec.Http[1].Fault)
+
+	// Stop call
+	_, err = HttpAbortAction{}.Stop(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the VirtualService was restored
+	require.True(t, proto.Equal(sharedSpec, &getVirtualService(t, clientset, "stripe-shared").Spec))
+}
+
+func Test_httpFaultRejectsWildcardHostsOnly(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	createServiceEntry(t, clientset, []string{"*.stripe.com"})
+
+	state := extvirtualservice.HostFaultActionState{}
+	_, err := HttpDelayAction{}.Prepare(context.Background(), &state, getTestPrepareRequest(map[string]any{
+		"delay":            1000.0,
+		"percentage":       100.0,
+		"sourceLabels":     []any{},
+		"headers":          []any{},
+		"headersMatchType": "exact",
+	}))
+	require.ErrorContains(t, err, "only wildcard hosts")
+}
+
+func createServiceEntry(t *testing.T, clientset versionedClient.Interface, hosts []string) {
+	_, err := clientset.NetworkingV1().ServiceEntries("payment").Create(context.Background(), &apiv1.ServiceEntry{
+		ObjectMeta: v1.ObjectMeta{Name: "stripe", Namespace: "payment"},
+		Spec: networkingv1.ServiceEntry{
+			Hosts:      hosts,
+			Ports:      []*networkingv1.ServicePort{{Number: 443, Name: "https", Protocol: "HTTPS"}},
+			Location:   networkingv1.ServiceEntry_MESH_EXTERNAL,
+			Resolution: networkingv1.ServiceEntry_DNS,
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+}
+
+func getVirtualService(t *testing.T, clientset versionedClient.Interface, name string) *apiv1.VirtualService {
+	vs, err := clientset.NetworkingV1().VirtualServices("payment").Get(context.Background(), name, v1.GetOptions{})
+	require.NoError(t, err)
+	return vs
+}
+
+func getTestPrepareRequest(config map[string]any) action_kit_api.PrepareActionRequestBody {
+	return extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":            {"payment"},
+				"istio.service-entry.name": {"stripe"},
+			},
+		},
+		Config: config,
+	})
+}
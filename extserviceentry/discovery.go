@@ -0,0 +1,168 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_api"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_commons"
+	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-kit/extbuild"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const discoveryBasePath = basePath + "/discovery"
+
+type serviceEntryDiscovery struct {
+}
+
+var (
+	_ discovery_kit_sdk.TargetDescriber    = (*serviceEntryDiscovery)(nil)
+	_ discovery_kit_sdk.AttributeDescriber = (*serviceEntryDiscovery)(nil)
+)
+
+func NewServiceEntryDiscovery() discovery_kit_sdk.TargetDiscovery {
+	discovery := &serviceEntryDiscovery{}
+	return discovery_kit_sdk.NewCachedTargetDiscovery(discovery,
+		discovery_kit_sdk.WithRefreshTargetsNow(),
+		discovery_kit_sdk.WithRefreshTargetsInterval(context.Background(), 30*time.Second),
+	)
+}
+
+func (d *serviceEntryDiscovery) Describe() discovery_kit_api.DiscoveryDescription {
+	return discovery_kit_api.DiscoveryDescription{
+		Id: ServiceEntryTargetID,
+		Discover: discovery_kit_api.DescribingEndpointReferenceWithCallInterval{
+			Method:       "GET",
+			Path:         discoveryBasePath + "/discovered-targets",
+			CallInterval: new("30s"),
+		},
+	}
+}
+
+func (d *serviceEntryDiscovery) DescribeTarget() discovery_kit_api.TargetDescription {
+	return discovery_kit_api.TargetDescription{
+		Id:       ServiceEntryTargetID,
+		Icon:     new(targetIcon),
+		Label:    discovery_kit_api.PluralLabel{One: "Service Entry", Other: "Service Entries"},
+		Category: new("Kubernetes"),
+		Version:  extbuild.GetSemverVersionStringOrUnknown(),
+
+		Table: discovery_kit_api.Table{
+			Columns: []discovery_kit_api.Column{
+				{Attribute: "istio.service-entry.name"},
+				{Attribute: "istio.service-entry.host"},
+				{Attribute: "k8s.namespace"},
+				{Attribute: "k8s.cluster-name"},
+			},
+			OrderBy: []discovery_kit_api.OrderBy{
+				{
+					Attribute: "istio.service-entry.name",
+					Direction: "ASC",
+				},
+			},
+		},
+	}
+}
+
+func (d *serviceEntryDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDescription {
+	return []discovery_kit_api.AttributeDescription{
+		{
+			Attribute: "istio.service-entry.name",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry",
+				Other: "Service Entries",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.host",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry host",
+				Other: "Service Entry hosts",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.port",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry port",
+				Other: "Service Entry ports",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.protocol",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry protocol",
+				Other: "Service Entry protocols",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.location",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry location",
+				Other: "Service Entry locations",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.resolution",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Service Entry resolution",
+				Other: "Service Entry resolutions",
+			},
+		},
+		{
+			Attribute: "istio.service-entry.export-to",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Exported to namespace",
+				Other: "Exported to namespaces",
+			},
+		},
+	}
+}
+
+func (d *serviceEntryDiscovery) DiscoverTargets(_ context.Context) ([]discovery_kit_api.Target, error) {
+	return getServiceEntryTargets(extclient.Istio), nil
+}
+
+func getServiceEntryTargets(client *extclient.IstioClient) []discovery_kit_api.Target {
+	serviceEntries := client.GetServiceEntries()
+	result := make([]discovery_kit_api.Target, len(serviceEntries))
+
+	for i, serviceEntry := range serviceEntries {
+		attributes := make(map[string][]string)
+		attributes["istio.service-entry.name"] = []string{serviceEntry.Name}
+		attributes["istio.service-entry.location"] = []string{serviceEntry.Spec.Location.String()}
+		attributes["istio.service-entry.resolution"] = []string{serviceEntry.Spec.Resolution.String()}
+		attributes["k8s.namespace"] = []string{serviceEntry.Namespace}
+		attributes["k8s.cluster-name"] = []string{extconfig.Config.ClusterName}
+
+		if len(serviceEntry.Spec.Hosts) > 0 {
+			attributes["istio.service-entry.host"] = slices.Clone(serviceEntry.Spec.Hosts)
+		}
+		for _, port := range serviceEntry.Spec.Ports {
+			attributes["istio.service-entry.port"] = extclient.AppendDistinct(attributes["istio.service-entry.port"], strconv.FormatUint(uint64(port.Number), 10))
+			attributes["istio.service-entry.protocol"] = extclient.AppendDistinct(attributes["istio.service-entry.protocol"], port.Protocol)
+		}
+		if len(serviceEntry.Spec.ExportTo) > 0 {
+			attributes["istio.service-entry.export-to"] = slices.Clone(serviceEntry.Spec.ExportTo)
+		}
+
+		for key, value := range serviceEntry.Labels {
+			attributes["k8s.service-entry.label."+key] = []string{value}
+		}
+
+		result[i] = discovery_kit_api.Target{
+			Id:         fmt.Sprintf("%s/%s/%s", extconfig.Config.ClusterName, serviceEntry.Namespace, serviceEntry.Name),
+			Label:      serviceEntry.Name,
+			TargetType: ServiceEntryTargetID,
+			Attributes: attributes,
+		}
+	}
+
+	return discovery_kit_commons.ApplyAttributeExcludes(result, extconfig.Config.DiscoveryAttributesExcludesServiceEntry)
+}
@@ -0,0 +1,91 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extserviceentry
+
+import (
+	"context"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
+	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
+	versionedClient "istio.io/client-go/pkg/clientset/versioned"
+	testclient "istio.io/client-go/pkg/clientset/versioned/fake"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/features"
+	clientfeaturestesting "k8s.io/client-go/features/testing"
+	k8stestclient "k8s.io/client-go/kubernetes/fake"
+	"testing"
+	"time"
+)
+
+func Test_getDiscoveredServiceEntries(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extconfig.Config.ClusterName = "development"
+	extconfig.Config.DiscoveryAttributesExcludesServiceEntry = []string{"k8s.service-entry.label.toIgnore"}
+
+	_, err := clientset.
+		NetworkingV1().
+		ServiceEntries("payment").
+		Create(context.Background(), &apiv1.ServiceEntry{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "stripe",
+				Namespace: "payment",
+				Labels: map[string]string{
+					"best-city": "Kevelaer",
+					"toIgnore":  "Bielefeld",
+				},
+			},
+			Spec: networkingv1.ServiceEntry{
+				Hosts: []string{"api.stripe.com", "files.stripe.com"},
+				Ports: []*networkingv1.ServicePort{
+					{Number: 443, Name: "https", Protocol: "HTTPS"},
+					{Number: 80, Name: "http", Protocol: "HTTP"},
+				},
+				Location:   networkingv1.ServiceEntry_MESH_EXTERNAL,
+				Resolution: networkingv1.ServiceEntry_DNS,
+				ExportTo:   []string{".", "checkout"},
+			},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(getServiceEntryTargets(client)) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then
+	targets := getServiceEntryTargets(client)
+	require.Len(t, targets, 1)
+	target := targets[0]
+	require.Equal(t, "development/payment/stripe", target.Id)
+	require.Equal(t, ServiceEntryTargetID, target.TargetType)
+	require.Equal(t, "stripe", target.Label)
+	require.Equal(t, map[string][]string{
+		"istio.service-entry.name":          {"stripe"},
+		"istio.service-entry.host":          {"api.stripe.com", "files.stripe.com"},
+		"istio.service-entry.port":          {"443", "80"},
+		"istio.service-entry.protocol":      {"HTTPS", "HTTP"},
+		"istio.service-entry.location":      {"MESH_EXTERNAL"},
+		"istio.service-entry.resolution":    {"DNS"},
+		"istio.service-entry.export-to":     {".", "checkout"},
+		"k8s.namespace":                     {"payment"},
+		"k8s.cluster-name":                  {"development"},
+		"k8s.service-entry.label.best-city": {"Kevelaer"},
+	}, target.Attributes)
+}
+
+func getTestClient(t testing.TB, stopCh <-chan struct{}) (*extclient.IstioClient, versionedClient.Interface) {
+	// Disable WatchListClient feature gate: the fake client doesn't support
+	// the bookmark events required by the WatchList stream, causing informers
+	// to hang indefinitely.
+	clientfeaturestesting.SetFeatureDuringTest(t, features.WatchListClient, false)
+	clientset := testclient.NewSimpleClientset()
+	client := extclient.NewIstioClient(clientset, k8stestclient.NewSimpleClientset(), stopCh)
+	return client, clientset
+}
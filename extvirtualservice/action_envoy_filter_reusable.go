@@ -0,0 +1,187 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	"google.golang.org/protobuf/types/known/structpb"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	apinetworkingv1alpha3 "istio.io/client-go/pkg/apis/networking/v1alpha3"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"math"
+	"slices"
+)
+
+type EnvoyFilterActionState struct {
+	ExecutionId   string
+	Namespace     string
+	Name          string
+	EnvoyFilters  []EnvoyFilterTarget
+	ConfigPatches []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch
+}
+
+type EnvoyFilterTarget struct {
+	Namespace      string
+	Name           string
+	WorkloadLabels map[string]string
+}
+
+func prepareEnvoyFilter(ctx context.Context,
+	state *EnvoyFilterActionState,
+	request action_kit_api.PrepareActionRequestBody,
+	toConfigPatches func(req action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error)) error {
+
+	state.ExecutionId = request.ExecutionId.String()
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["istio.virtual-service.name"][0]
+
+	vs, err := extclient.Istio.GetVirtualService(ctx, state.Namespace, state.Name)
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to fetch VirtualService %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+
+	workloads := extclient.Istio.GetWorkloads(vs.Namespace, getDestinationHosts(vs))
+	if len(workloads) == 0 {
+		return extension_kit.ToError(fmt.Sprintf("Failed to find the workloads behind the destinations of VirtualService %s in namespace %s.", state.Name, state.Namespace), nil)
+	}
+
+	state.EnvoyFilters = make([]EnvoyFilterTarget, len(workloads))
+	for i, workload := range workloads {
+		state.EnvoyFilters[i] = EnvoyFilterTarget{
+			Namespace:      workload.Namespace,
+			Name:           fmt.Sprintf("steadybit-%s-%d", state.ExecutionId, i),
+			WorkloadLabels: workload.Labels,
+		}
+	}
+
+	state.ConfigPatches, err = toConfigPatches(request)
+	if err != nil {
+		return extension_kit.ToError("Failed prepare attack", err)
+	}
+	return nil
+}
+
+func startEnvoyFilter(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	messages := make([]action_kit_api.Message, 0, len(state.EnvoyFilters))
+
+	for i, target := range state.EnvoyFilters {
+		err := extclient.Istio.CreateEnvoyFilter(ctx, toEnvoyFilter(state, target))
+		if err != nil {
+			// Don't leave the already created EnvoyFilters behind, the stop call will not happen for a failed start.
+			_ = deleteEnvoyFilters(ctx, state.EnvoyFilters[:i])
+			return nil, extension_kit.ToError(fmt.Sprintf("Failed to create EnvoyFilter %s in namespace %s through Kubernetes API.", target.Name, target.Namespace), err)
+		}
+
+		messages = append(messages, action_kit_api.Message{
+			Level:   new(action_kit_api.Info),
+			Message: fmt.Sprintf("Created EnvoyFilter %s in namespace %s for workloads with labels %s.", target.Name, target.Namespace, extclient.FormatLabels(target.WorkloadLabels)),
+		})
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &messages,
+	}, nil
+}
+
+func stopEnvoyFilter(ctx context.Context, state *EnvoyFilterActionState) error {
+	err := deleteEnvoyFilters(ctx, state.EnvoyFilters)
+	if err != nil {
+		return extension_kit.ToError(fmt.Sprintf("Failed to delete EnvoyFilters for VirtualService %s in namespace %s through Kubernetes API.", state.Name, state.Namespace), err)
+	}
+	return nil
+}
+
+func deleteEnvoyFilters(ctx context.Context, targets []EnvoyFilterTarget) error {
+	var errs []error
+	for _, target := range targets {
+		if err := extclient.Istio.DeleteEnvoyFilter(ctx, target.Namespace, target.Name); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func toEnvoyFilter(state *EnvoyFilterActionState, target EnvoyFilterTarget) *apinetworkingv1alpha3.EnvoyFilter {
+	configPatches := make([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, len(state.ConfigPatches))
+	for i, configPatch := range state.ConfigPatches {
+		configPatches[i] = configPatch.DeepCopy()
+	}
+
+	return &apinetworkingv1alpha3.EnvoyFilter{
+		ObjectMeta: v1.ObjectMeta{
+			Name:      target.Name,
+			Namespace: target.Namespace,
+			Labels: map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			},
+		},
+		Spec: networkingv1alpha3.EnvoyFilter{
+			WorkloadSelector: &networkingv1alpha3.WorkloadSelector{
+				Labels: target.WorkloadLabels,
+			},
+			ConfigPatches: configPatches,
+		},
+	}
+}
+
+// getDestinationHosts returns the distinct hosts of all route destinations of the VirtualService.
+func getDestinationHosts(vs *apinetworkingv1.VirtualService) []string {
+	var hosts []string
+	for _, destination := range extclient.GetRouteDestinations(vs) {
+		if !slices.Contains(hosts, destination.Host) {
+			hosts = append(hosts, destination.Host)
+		}
+	}
+	return hosts
+}
+
+// toInboundHTTPFilterPatch inserts the given HTTP filter in front of the router filter of the inbound listeners of the
+// selected workloads.
+func toInboundHTTPFilterPatch(filterName string, typedConfig map[string]any) (*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	value, err := structpb.NewStruct(map[string]any{
+		"name":         filterName,
+		"typed_config": typedConfig,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{
+		ApplyTo: networkingv1alpha3.EnvoyFilter_HTTP_FILTER,
+		Match: &networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectMatch{
+			Context: networkingv1alpha3.EnvoyFilter_SIDECAR_INBOUND,
+			ObjectTypes: &networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectMatch_Listener{
+				Listener: &networkingv1alpha3.EnvoyFilter_ListenerMatch{
+					FilterChain: &networkingv1alpha3.EnvoyFilter_ListenerMatch_FilterChainMatch{
+						Filter: &networkingv1alpha3.EnvoyFilter_ListenerMatch_FilterMatch{
+							Name: "envoy.filters.network.http_connection_manager",
+							SubFilter: &networkingv1alpha3.EnvoyFilter_ListenerMatch_SubFilterMatch{
+								Name: "envoy.filters.http.router",
+							},
+						},
+					},
+				},
+			},
+		},
+		Patch: &networkingv1alpha3.EnvoyFilter_Patch{
+			Operation: networkingv1alpha3.EnvoyFilter_Patch_INSERT_BEFORE,
+			Value:     value,
+		},
+	}, nil
+}
+
+// toFractionalPercent converts a percentage into an Envoy FractionalPercent, keeping up to four decimal places.
+func toFractionalPercent(percentage float64) map[string]any {
+	return map[string]any{
+		"numerator":   int64(math.Round(percentage * 10000)),
+		"denominator": "MILLION",
+	}
+}
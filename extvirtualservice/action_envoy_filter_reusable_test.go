@@ -0,0 +1,181 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"github.com/google/uuid"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	corev1 "k8s.io/api/core/v1"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"testing"
+)
+
+func Test_envoyFilterLifecycle(t *testing.T) {
+	// General preparation
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh,
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "default"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "reviews"}},
+		},
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "ratings", Namespace: "backend"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "ratings", "version": "v1"}},
+		},
+		&corev1.Service{
+			ObjectMeta: v1.ObjectMeta{Name: "httpbin", Namespace: "org"},
+			Spec:       corev1.ServiceSpec{Selector: map[string]string{"app": "httpbin"}},
+		},
+	)
+	extclient.Istio = client
+	extconfig.Config.ClusterName = "development"
+
+	_, err := clientset.
+		NetworkingV1().
+		VirtualServices("default").
+		Create(context.Background(), &apinetworkingv1.VirtualService{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop",
+				Namespace: "default",
+			},
+			Spec: networkingv1.VirtualService{
+				Http: []*networkingv1.HTTPRoute{
+					{
+						Route: []*networkingv1.HTTPRouteDestination{
+							{Destination: &networkingv1.Destination{Host: "reviews"}},
+							{Destination: &networkingv1.Destination{Host: "ratings.backend.svc.cluster.local"}},
+							{Destination: &networkingv1.Destination{Host: "unknown"}},
+						},
+					},
+					{
+						Route: []*networkingv1.HTTPRouteDestination{
+							{Destination: &networkingv1.Destination{Host: "reviews.default.svc"}},
+							{Destination: &networkingv1.Destination{Host: "httpbin.org"}},
+						},
+					},
+				},
+			},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// Prepare call
+	prepareRequest := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":              {"default"},
+				"istio.virtual-service.name": {"shop"},
+			},
+		},
+		Config: map[string]any{
+			"bandwidth":  64.0,
+			"percentage": 100.0,
+		},
+	})
+	state := EnvoyFilterActionState{}
+	err = prepareEnvoyFilter(context.Background(), &state, prepareRequest, toResponseBandwidthConfigPatches)
+	require.NoError(t, err)
+	require.Equal(t, []EnvoyFilterTarget{
+		{
+			Namespace:      "default",
+			Name:           "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-0",
+			WorkloadLabels: map[string]string{"app": "reviews"},
+		},
+		{
+			Namespace:      "backend",
+			Name:           "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-1",
+			WorkloadLabels: map[string]string{"app": "ratings", "version": "v1"},
+		},
+	}, state.EnvoyFilters)
+
+	// Start call
+	result, err := startEnvoyFilter(context.Background(), &state)
+	require.NoError(t, err)
+	require.Len(t, *result.Messages, 2)
+
+	// Check that the EnvoyFilters were created
+	envoyFilter, err := clientset.
+		NetworkingV1alpha3().
+		EnvoyFilters("backend").
+		Get(context.Background(), "steadybit-22955847-b455-461d-8f9b-61ef1ef05060-1", v1.GetOptions{})
+	require.NoError(t, err)
+	require.Equal(t, map[string]string{"app": "ratings", "version": "v1"}, envoyFilter.Spec.WorkloadSelector.Labels)
+	require.Equal(t, extclient.ManagedByValue, envoyFilter.Labels[extclient.ManagedByLabel])
+	require.Equal(t, "22955847-b455-461d-8f9b-61ef1ef05060", envoyFilter.Labels[extclient.ExecutionIdLabel])
+	require.Len(t, envoyFilter.Spec.ConfigPatches, 1)
+
+	// Stop call
+	err = stopEnvoyFilter(context.Background(), &state)
+	require.NoError(t, err)
+
+	// Check that the EnvoyFilters were deleted
+	envoyFilters, err := clientset.
+		NetworkingV1alpha3().
+		EnvoyFilters("").
+		List(context.Background(), v1.ListOptions{})
+	require.NoError(t, err)
+	require.Empty(t, envoyFilters.Items)
+
+	// Stopping again must not fail
+	err = stopEnvoyFilter(context.Background(), &state)
+	require.NoError(t, err)
+}
+
+func Test_envoyFilterPrepare_without_workloads(t *testing.T) {
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extclient.Istio = client
+
+	_, err := clientset.
+		NetworkingV1().
+		VirtualServices("default").
+		Create(context.Background(), &apinetworkingv1.VirtualService{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      "shop",
+				Namespace: "default",
+			},
+			Spec: networkingv1.VirtualService{
+				Http: []*networkingv1.HTTPRoute{
+					{
+						Route: []*networkingv1.HTTPRouteDestination{
+							{Destination: &networkingv1.Destination{Host: "reviews"}},
+						},
+					},
+				},
+			},
+		}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	prepareRequest := extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		ExecutionId: uuid.MustParse("22955847-b455-461d-8f9b-61ef1ef05060"),
+		Target: &action_kit_api.Target{
+			Attributes: map[string][]string{
+				"k8s.namespace":              {"default"},
+				"istio.virtual-service.name": {"shop"},
+			},
+		},
+		Config: map[string]any{
+			"bandwidth":  64.0,
+			"percentage": 100.0,
+		},
+	})
+	state := EnvoyFilterActionState{}
+	err = prepareEnvoyFilter(context.Background(), &state, prepareRequest, toResponseBandwidthConfigPatches)
+	require.Error(t, err)
+}
+
+func Test_toFractionalPercent(t *testing.T) {
+	require.Equal(t, int64(1000000), toFractionalPercent(100)["numerator"])
+	require.Equal(t, int64(123456), toFractionalPercent(12.3456)["numerator"])
+	require.Equal(t, int64(1), toFractionalPercent(0.0001)["numerator"])
+}
@@ -144,7 +144,7 @@ func (f GrpcAbortAction) Describe() action_kit_api.ActionDescription {
 				Required: new(true),
 				Order:    new(2),
 			},
-		}, getAdvancedTargetingParameters(3)...),
+		}, GetAdvancedTargetingParameters(3)...),
 		Prepare: action_kit_api.MutatingEndpointReference{},
 		Start:   action_kit_api.MutatingEndpointReference{},
 		Stop:    new(action_kit_api.MutatingEndpointReference{}),
@@ -0,0 +1,229 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-istio/extclient"
+	extension_kit "github.com/steadybit/extension-kit"
+	networkingv1 "istio.io/api/networking/v1"
+	"slices"
+)
+
+// HostFaultActionState is the state of actions injecting HTTP faults into the traffic to hosts, which are not
+// necessarily routed through a VirtualService yet.
+type HostFaultActionState struct {
+	ExecutionId       string
+	FaultyRoutePrefix string
+	Fault             *networkingv1.HTTPFaultInjection
+	SourceLabels      map[string]string
+	Headers           map[string]*networkingv1.StringMatch
+	VirtualServices   []HostFaultVirtualService
+}
+
+// HostFaultVirtualService is a VirtualService the fault is injected into. Temporary VirtualServices are created for
+// hosts without any VirtualService and deleted again on stop. Restricted tells that the VirtualService covers other
+// hosts too, explicitly or through a wildcard, so the fault is restricted to requests for the host.
+type HostFaultVirtualService struct {
+	Namespace  string
+	Name       string
+	Host       string
+	Temporary  bool
+	Restricted bool
+}
+
+// GetHostAbortParameters returns the parameters of HTTP abort actions on hosts, configuring the fault built by
+// ToHTTPAbortFault.
+func GetHostAbortParameters() []action_kit_api.ActionParameter {
+	return append([]action_kit_api.ActionParameter{
+		{
+			Name:         "duration",
+			Label:        "Duration",
+			Description:  new("Duration defining for how long the HTTP abort should be injected."),
+			Type:         action_kit_api.ActionParameterTypeDuration,
+			DefaultValue: new("30s"),
+			Required:     new(true),
+			Order:        new(0),
+		},
+		{
+			Name:         "percentage",
+			Label:        "Percentage",
+			Description:  new("Percentage of requests on which the abort will be injected."),
+			Type:         action_kit_api.ActionParameterTypePercentage,
+			DefaultValue: new("50"),
+			Required:     new(true),
+			Order:        new(1),
+		},
+		{
+			Name:         "statusCode",
+			Label:        "HTTP status code",
+			Description:  new("HTTP status code to use for aborted requests."),
+			Type:         action_kit_api.ActionParameterTypeInteger,
+			DefaultValue: new("500"),
+			MinValue:     new(100),
+			MaxValue:     new(599),
+			Required:     new(true),
+			Order:        new(2),
+		},
+	}, GetAdvancedTargetingParameters(3)...)
+}
+
+// GetHostDelayParameters returns the parameters of HTTP delay actions on hosts, configuring the fault built by
+// ToHTTPDelayFault.
+func GetHostDelayParameters() []action_kit_api.ActionParameter {
+	return append([]action_kit_api.ActionParameter{
+		{
+			Name:         "duration",
+			Label:        "Duration",
+			Description:  new("Duration defining for how long the HTTP delay should be injected."),
+			Type:         action_kit_api.ActionParameterTypeDuration,
+			DefaultValue: new("30s"),
+			Required:     new(true),
+			Order:        new(0),
+		},
+		{
+			Name:         "percentage",
+			Label:        "Percentage",
+			Description:  new("Percentage of requests on which the delay will be injected."),
+			Type:         action_kit_api.ActionParameterTypePercentage,
+			DefaultValue: new("50"),
+			Required:     new(true),
+			Order:        new(1),
+		},
+		{
+			Name:         "delay",
+			Label:        "Delay",
+			Description:  new("Fixed delay before forwarding the request."),
+			Type:         action_kit_api.ActionParameterTypeDuration,
+			DefaultValue: new("5s"),
+			Required:     new(true),
+			Order:        new(2),
+		},
+	}, GetAdvancedTargetingParameters(3)...)
+}
+
+// PrepareHostFault determines the VirtualServices for the given fully qualified hosts. Existing VirtualServices get the
+// fault added to their routes, restricted to the host unless it is the only host of the VirtualService. For the other
+// hosts a temporary VirtualService is created in the given namespace.
+func PrepareHostFault(state *HostFaultActionState,
+	request action_kit_api.PrepareActionRequestBody,
+	namespace string,
+	hosts []string,
+	toFault func(req action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection) error {
+
+	headers, sourceLabels, err := toFaultMatch(request)
+	if err != nil {
+		return extension_kit.ToError("Failed prepare attack", err)
+	}
+
+	state.ExecutionId = request.ExecutionId.String()
+	state.FaultyRoutePrefix = fmt.Sprintf("steadybit-injected-fault_%s", request.ExecutionId)
+	state.Fault = toFault(request)
+	state.Headers = headers
+	state.SourceLabels = sourceLabels
+	state.VirtualServices = nil
+
+	temporaryCount := 0
+	for _, host := range hosts {
+		virtualServices := extclient.Istio.GetVirtualServicesForHost(host)
+		if len(virtualServices) == 0 {
+			state.VirtualServices = append(state.VirtualServices, HostFaultVirtualService{
+				Namespace: namespace,
+				Name:      fmt.Sprintf("steadybit-%s-%d", state.ExecutionId, temporaryCount),
+				Host:      host,
+				Temporary: true,
+			})
+			temporaryCount++
+			continue
+		}
+
+		for _, vs := range virtualServices {
+			if len(vs.Spec.Http) == 0 {
+				return extension_kit.ToError(fmt.Sprintf("VirtualService %s in namespace %s routes the traffic to %s without HTTP routes, HTTP faults cannot be injected.", vs.Name, vs.Namespace, host), nil)
+			}
+			if slices.ContainsFunc(state.VirtualServices, func(existing HostFaultVirtualService) bool {
+				return !existing.Temporary && existing.Namespace == vs.Namespace && existing.Name == vs.Name && existing.Host == host
+			}) {
+				continue
+			}
+			// Adding the fault to all routes would affect the other hosts of the VirtualService as well.
+			restricted := slices.ContainsFunc(vs.Spec.Hosts, func(virtualServiceHost string) bool {
+				return extclient.ToFullyQualifiedHost(vs.Namespace, virtualServiceHost) != host
+			})
+			state.VirtualServices = append(state.VirtualServices, HostFaultVirtualService{
+				Namespace:  vs.Namespace,
+				Name:       vs.Name,
+				Host:       host,
+				Restricted: restricted,
+			})
+		}
+	}
+
+	if len(state.VirtualServices) == 0 {
+		return extension_kit.ToError("Failed to find any host to inject the HTTP fault for.", nil)
+	}
+	return nil
+}
+
+func StartHostFault(ctx context.Context, state *HostFaultActionState) (*action_kit_api.StartResult, error) {
+	messages := make([]action_kit_api.Message, 0, len(state.VirtualServices))
+
+	for i, target := range state.VirtualServices {
+		var err error
+		var message string
+		if target.Temporary {
+			err = extclient.Istio.CreateVirtualServiceWithHTTPFault(ctx, target.Namespace, target.Name, map[string]string{
+				extclient.ManagedByLabel:   extclient.ManagedByValue,
+				extclient.ExecutionIdLabel: state.ExecutionId,
+			}, target.Host, state.FaultyRoutePrefix, state.Fault, state.SourceLabels, state.Headers)
+			message = fmt.Sprintf("Created VirtualService %s in namespace %s for host %s.", target.Name, target.Namespace, target.Host)
+		} else if target.Restricted {
+			err = extclient.Istio.AddHTTPFaultForAuthority(ctx, target.Namespace, target.Name, target.Host, state.FaultyRoutePrefix, state.Fault, state.SourceLabels, state.Headers)
+			message = fmt.Sprintf("Added HTTP fault for host %s to VirtualService %s in namespace %s.", target.Host, target.Name, target.Namespace)
+		} else {
+			err = extclient.Istio.AddHTTPFault(ctx, target.Namespace, target.Name, state.FaultyRoutePrefix, state.Fault, state.SourceLabels, state.Headers)
+			message = fmt.Sprintf("Added HTTP fault to VirtualService %s in namespace %s for host %s.", target.Name, target.Namespace, target.Host)
+		}
+		if err != nil {
+			_ = removeHostFaults(ctx, state.FaultyRoutePrefix, state.VirtualServices[:i])
+			return nil, extension_kit.ToError(fmt.Sprintf("Failed to inject HTTP fault through VirtualService %s in namespace %s through Kubernetes API.", target.Name, target.Namespace), err)
+		}
+
+		messages = append(messages, action_kit_api.Message{
+			Level:   new(action_kit_api.Info),
+			Message: message,
+		})
+	}
+
+	return &action_kit_api.StartResult{
+		Messages: &messages,
+	}, nil
+}
+
+func StopHostFault(ctx context.Context, state *HostFaultActionState) error {
+	err := removeHostFaults(ctx, state.FaultyRoutePrefix, state.VirtualServices)
+	if err != nil {
+		return extension_kit.ToError("Failed to remove HTTP faults through Kubernetes API.", err)
+	}
+	return nil
+}
+
+func removeHostFaults(ctx context.Context, faultyRoutePrefix string, targets []HostFaultVirtualService) error {
+	var errs []error
+	for _, target := range targets {
+		var err error
+		if target.Temporary {
+			err = extclient.Istio.DeleteVirtualService(ctx, target.Namespace, target.Name)
+		} else {
+			err = extclient.Istio.RemoveAllFaults(ctx, target.Namespace, target.Name, faultyRoutePrefix)
+		}
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
@@ -75,7 +75,7 @@ func (f HttpAbortAction) Describe() action_kit_api.ActionDescription {
 				Required:     new(true),
 				Order:        new(2),
 			},
-		}, getAdvancedTargetingParameters(3)...),
+		}, GetAdvancedTargetingParameters(3)...),
 		Prepare: action_kit_api.MutatingEndpointReference{},
 		Start:   action_kit_api.MutatingEndpointReference{},
 		Stop:    new(action_kit_api.MutatingEndpointReference{}),
@@ -83,7 +83,7 @@ func (f HttpAbortAction) Describe() action_kit_api.ActionDescription {
 }
 
 func (f HttpAbortAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
-	return nil, prepareVirtualServiceFault(state, request, toHTTPAbortFault)
+	return nil, prepareVirtualServiceFault(state, request, ToHTTPAbortFault)
 }
 
 func (f HttpAbortAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
@@ -94,7 +94,8 @@ func (f HttpAbortAction) Stop(ctx context.Context, state *ActionState) (*action_
 	return nil, stopVirtualServiceFault(ctx, state)
 }
 
-func toHTTPAbortFault(request action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection {
+// ToHTTPAbortFault builds the abort fault configured through the statusCode and percentage parameters.
+func ToHTTPAbortFault(request action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection {
 	return &networkingv1.HTTPFaultInjection{
 		Abort: &networkingv1.HTTPFaultInjection_Abort{
 			ErrorType: &networkingv1.HTTPFaultInjection_Abort_HttpStatus{
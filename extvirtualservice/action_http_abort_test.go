@@ -11,7 +11,7 @@ import (
 	"testing"
 )
 
-func Test_toHTTPAbortFault(t *testing.T) {
+func TestToHTTPAbortFault(t *testing.T) {
 	type args struct {
 		request action_kit_api.PrepareActionRequestBody
 	}
@@ -44,8 +44,8 @@ func Test_toHTTPAbortFault(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := toHTTPAbortFault(tt.args.request); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("toHTTPAbortFault() = %v, want %v", got, tt.want)
+			if got := ToHTTPAbortFault(tt.args.request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToHTTPAbortFault() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -75,7 +75,7 @@ func (f HttpDelayAction) Describe() action_kit_api.ActionDescription {
 				Required:     new(true),
 				Order:        new(2),
 			},
-		}, getAdvancedTargetingParameters(3)...),
+		}, GetAdvancedTargetingParameters(3)...),
 		Prepare: action_kit_api.MutatingEndpointReference{},
 		Start:   action_kit_api.MutatingEndpointReference{},
 		Stop:    new(action_kit_api.MutatingEndpointReference{}),
@@ -83,7 +83,7 @@ func (f HttpDelayAction) Describe() action_kit_api.ActionDescription {
 }
 
 func (f HttpDelayAction) Prepare(_ context.Context, state *ActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
-	return nil, prepareVirtualServiceFault(state, request, toHTTPDelayFault)
+	return nil, prepareVirtualServiceFault(state, request, ToHTTPDelayFault)
 }
 
 func (f HttpDelayAction) Start(ctx context.Context, state *ActionState) (*action_kit_api.StartResult, error) {
@@ -94,7 +94,8 @@ func (f HttpDelayAction) Stop(ctx context.Context, state *ActionState) (*action_
 	return nil, stopVirtualServiceFault(ctx, state)
 }
 
-func toHTTPDelayFault(request action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection {
+// ToHTTPDelayFault builds the delay fault configured through the delay and percentage parameters.
+func ToHTTPDelayFault(request action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection {
 	return &networkingv1.HTTPFaultInjection{
 		Delay: &networkingv1.HTTPFaultInjection_Delay{
 			HttpDelayType: &networkingv1.HTTPFaultInjection_Delay_FixedDelay{
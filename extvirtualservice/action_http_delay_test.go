@@ -13,7 +13,7 @@ import (
 	"time"
 )
 
-func Test_toHTTPDelayFault(t *testing.T) {
+func TestToHTTPDelayFault(t *testing.T) {
 	type args struct {
 		request action_kit_api.PrepareActionRequestBody
 	}
@@ -46,8 +46,8 @@ func Test_toHTTPDelayFault(t *testing.T) {
 	}
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
-			if got := toHTTPDelayFault(tt.args.request); !reflect.DeepEqual(got, tt.want) {
-				t.Errorf("toHTTPDelayFault() = %v, want %v", got, tt.want)
+			if got := ToHTTPDelayFault(tt.args.request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("ToHTTPDelayFault() = %v, want %v", got, tt.want)
 			}
 		})
 	}
@@ -0,0 +1,138 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+type HttpHeaderControlledFaultsAction struct {
+}
+
+func NewHttpHeaderControlledFaultsAction() action_kit_sdk.Action[EnvoyFilterActionState] {
+	return HttpHeaderControlledFaultsAction{}
+}
+
+var _ action_kit_sdk.Action[EnvoyFilterActionState] = (*HttpHeaderControlledFaultsAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EnvoyFilterActionState] = (*HttpHeaderControlledFaultsAction)(nil)
+
+func (f HttpHeaderControlledFaultsAction) NewEmptyState() EnvoyFilterActionState {
+	return EnvoyFilterActionState{}
+}
+
+func (f HttpHeaderControlledFaultsAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.http.header-controlled-faults", VirtualServiceTargetID),
+		Label:       "HTTP Header-Controlled Faults",
+		Description: "Enables header-controlled Envoy faults on the workloads behind the destinations of the targeted virtual services through a temporary EnvoyFilter. During the attack, each request can ask for its own fault via the x-envoy-fault-* headers, e.g. from integration or load tests.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(targetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: VirtualServiceTargetID,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "name",
+					Query: "istio.virtual-service.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Istio"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the header-controlled faults should be enabled."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "abort",
+				Label:        "Header-controlled aborts",
+				Description:  new("Allow requests to ask for an abort via the x-envoy-fault-abort-request (HTTP status) and x-envoy-fault-abort-grpc-request (gRPC status) headers."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "delay",
+				Label:        "Header-controlled delays",
+				Description:  new("Allow requests to ask for a delay via the x-envoy-fault-delay-request header (milliseconds)."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("true"),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "responseRateLimit",
+				Label:        "Header-controlled response bandwidth",
+				Description:  new("Allow requests to ask for a response bandwidth limit via the x-envoy-fault-throughput-response header (KiB/s)."),
+				Type:         action_kit_api.ActionParameterTypeBoolean,
+				DefaultValue: new("false"),
+				Required:     new(true),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpHeaderControlledFaultsAction) Prepare(ctx context.Context, state *EnvoyFilterActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareEnvoyFilter(ctx, state, request, toHeaderControlledFaultsConfigPatches)
+}
+
+func (f HttpHeaderControlledFaultsAction) Start(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	return startEnvoyFilter(ctx, state)
+}
+
+func (f HttpHeaderControlledFaultsAction) Stop(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopEnvoyFilter(ctx, state)
+}
+
+func toHeaderControlledFaultsConfigPatches(request action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	// The percentage of header-controlled faults is only the upper bound, the x-envoy-fault-*-percentage headers can lower it per request.
+	typedConfig := map[string]any{
+		"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+	}
+	if extutil.ToBool(request.Config["abort"]) {
+		typedConfig["abort"] = map[string]any{
+			"header_abort": map[string]any{},
+			"percentage":   toFractionalPercent(100),
+		}
+	}
+	if extutil.ToBool(request.Config["delay"]) {
+		typedConfig["delay"] = map[string]any{
+			"header_delay": map[string]any{},
+			"percentage":   toFractionalPercent(100),
+		}
+	}
+	if extutil.ToBool(request.Config["responseRateLimit"]) {
+		typedConfig["response_rate_limit"] = map[string]any{
+			"header_limit": map[string]any{},
+			"percentage":   toFractionalPercent(100),
+		}
+	}
+	if len(typedConfig) == 1 {
+		return nil, errors.New("at least one kind of header-controlled fault must be enabled")
+	}
+
+	patch, err := toInboundHTTPFilterPatch("steadybit.filters.http.header_controlled_faults", typedConfig)
+	if err != nil {
+		return nil, err
+	}
+	return []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{patch}, nil
+}
@@ -0,0 +1,48 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_toHeaderControlledFaultsConfigPatches(t *testing.T) {
+	patches, err := toHeaderControlledFaultsConfigPatches(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"abort":             true,
+			"delay":             false,
+			"responseRateLimit": true,
+		},
+	}))
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	require.Equal(t, map[string]any{
+		"name": "steadybit.filters.http.header_controlled_faults",
+		"typed_config": map[string]any{
+			"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+			"abort": map[string]any{
+				"header_abort": map[string]any{},
+				"percentage":   map[string]any{"numerator": 1000000.0, "denominator": "MILLION"},
+			},
+			"response_rate_limit": map[string]any{
+				"header_limit": map[string]any{},
+				"percentage":   map[string]any{"numerator": 1000000.0, "denominator": "MILLION"},
+			},
+		},
+	}, patches[0].Patch.Value.AsMap())
+}
+
+func Test_toHeaderControlledFaultsConfigPatches_requires_a_fault(t *testing.T) {
+	_, err := toHeaderControlledFaultsConfigPatches(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"abort":             false,
+			"delay":             false,
+			"responseRateLimit": false,
+		},
+	}))
+	require.Error(t, err)
+}
@@ -0,0 +1,143 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"math"
+	"strconv"
+	"time"
+)
+
+type HttpLocalRateLimitAction struct {
+}
+
+func NewHttpLocalRateLimitAction() action_kit_sdk.Action[EnvoyFilterActionState] {
+	return HttpLocalRateLimitAction{}
+}
+
+var _ action_kit_sdk.Action[EnvoyFilterActionState] = (*HttpLocalRateLimitAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EnvoyFilterActionState] = (*HttpLocalRateLimitAction)(nil)
+
+func (f HttpLocalRateLimitAction) NewEmptyState() EnvoyFilterActionState {
+	return EnvoyFilterActionState{}
+}
+
+func (f HttpLocalRateLimitAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.http.local-rate-limit", VirtualServiceTargetID),
+		Label:       "HTTP Local Rate Limit",
+		Description: "Installs an Envoy local rate limit on the workloads behind the destinations of the targeted virtual services through a temporary EnvoyFilter. Requests exceeding the token bucket are rejected with HTTP 429, including rate limit and Retry-After headers.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(targetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: VirtualServiceTargetID,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "name",
+					Query: "istio.virtual-service.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Istio"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the rate limit should be installed."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "maxTokens",
+				Label:        "Max tokens",
+				Description:  new("Maximum number of tokens in the bucket, i.e. the number of requests allowed in a burst."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("10"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "tokensPerFill",
+				Label:        "Tokens per fill",
+				Description:  new("Number of tokens added to the bucket on every fill interval."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("10"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(2),
+			},
+			{
+				Name:         "fillInterval",
+				Label:        "Fill interval",
+				Description:  new("Interval in which the bucket is refilled. Also used for the Retry-After header of rejected requests."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("1s"),
+				MinValue:     new(50),
+				Required:     new(true),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpLocalRateLimitAction) Prepare(ctx context.Context, state *EnvoyFilterActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareEnvoyFilter(ctx, state, request, toLocalRateLimitConfigPatches)
+}
+
+func (f HttpLocalRateLimitAction) Start(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	return startEnvoyFilter(ctx, state)
+}
+
+func (f HttpLocalRateLimitAction) Stop(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopEnvoyFilter(ctx, state)
+}
+
+func toLocalRateLimitConfigPatches(request action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	fillInterval := time.Millisecond * time.Duration(extutil.ToInt64(request.Config["fillInterval"]))
+	alwaysOn := map[string]any{
+		"runtime_key":   "steadybit_local_rate_limit",
+		"default_value": toFractionalPercent(100),
+	}
+
+	patch, err := toInboundHTTPFilterPatch("steadybit.filters.http.local_rate_limit", map[string]any{
+		"@type":       "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit",
+		"stat_prefix": "steadybit_local_rate_limit",
+		"token_bucket": map[string]any{
+			"max_tokens":      extutil.ToInt64(request.Config["maxTokens"]),
+			"tokens_per_fill": extutil.ToInt64(request.Config["tokensPerFill"]),
+			"fill_interval":   fmt.Sprintf("%.3fs", fillInterval.Seconds()),
+		},
+		"filter_enabled":             alwaysOn,
+		"filter_enforced":            alwaysOn,
+		"enable_x_ratelimit_headers": "DRAFT_VERSION_03",
+		"response_headers_to_add": []any{
+			map[string]any{
+				"append_action": "OVERWRITE_IF_EXISTS_OR_ADD",
+				"header": map[string]any{
+					"key":   "retry-after",
+					"value": strconv.FormatFloat(math.Ceil(fillInterval.Seconds()), 'f', 0, 64),
+				},
+			},
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{patch}, nil
+}
@@ -0,0 +1,42 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_toLocalRateLimitConfigPatches(t *testing.T) {
+	patches, err := toLocalRateLimitConfigPatches(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"maxTokens":     5.0,
+			"tokensPerFill": 2.0,
+			"fillInterval":  1500.0,
+		},
+	}))
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+
+	value := patches[0].Patch.Value.AsMap()
+	require.Equal(t, "steadybit.filters.http.local_rate_limit", value["name"])
+	typedConfig := value["typed_config"].(map[string]any)
+	require.Equal(t, "type.googleapis.com/envoy.extensions.filters.http.local_ratelimit.v3.LocalRateLimit", typedConfig["@type"])
+	require.Equal(t, map[string]any{
+		"max_tokens":      5.0,
+		"tokens_per_fill": 2.0,
+		"fill_interval":   "1.500s",
+	}, typedConfig["token_bucket"])
+	require.Equal(t, []any{
+		map[string]any{
+			"append_action": "OVERWRITE_IF_EXISTS_OR_ADD",
+			"header": map[string]any{
+				"key":   "retry-after",
+				"value": "2",
+			},
+		},
+	}, typedConfig["response_headers_to_add"])
+}
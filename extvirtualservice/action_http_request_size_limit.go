@@ -0,0 +1,97 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+type HttpRequestSizeLimitAction struct {
+}
+
+func NewHttpRequestSizeLimitAction() action_kit_sdk.Action[EnvoyFilterActionState] {
+	return HttpRequestSizeLimitAction{}
+}
+
+var _ action_kit_sdk.Action[EnvoyFilterActionState] = (*HttpRequestSizeLimitAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EnvoyFilterActionState] = (*HttpRequestSizeLimitAction)(nil)
+
+func (f HttpRequestSizeLimitAction) NewEmptyState() EnvoyFilterActionState {
+	return EnvoyFilterActionState{}
+}
+
+func (f HttpRequestSizeLimitAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.http.request-size-limit", VirtualServiceTargetID),
+		Label:       "HTTP Request Size Limit",
+		Description: "Limits the request body size accepted by the workloads behind the destinations of the targeted virtual services through a temporary EnvoyFilter. Larger requests, e.g. uploads, are rejected with HTTP 413.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(targetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: VirtualServiceTargetID,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "name",
+					Query: "istio.virtual-service.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Istio"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the request size should be limited."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "maxRequestBytes",
+				Label:        "Max request size (bytes)",
+				Description:  new("Maximum size of a request body in bytes. Larger requests are rejected with HTTP 413."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("1024"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(1),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpRequestSizeLimitAction) Prepare(ctx context.Context, state *EnvoyFilterActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareEnvoyFilter(ctx, state, request, toRequestSizeLimitConfigPatches)
+}
+
+func (f HttpRequestSizeLimitAction) Start(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	return startEnvoyFilter(ctx, state)
+}
+
+func (f HttpRequestSizeLimitAction) Stop(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopEnvoyFilter(ctx, state)
+}
+
+func toRequestSizeLimitConfigPatches(request action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	patch, err := toInboundHTTPFilterPatch("steadybit.filters.http.request_size_limit", map[string]any{
+		"@type":             "type.googleapis.com/envoy.extensions.filters.http.buffer.v3.Buffer",
+		"max_request_bytes": extutil.ToInt64(request.Config["maxRequestBytes"]),
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{patch}, nil
+}
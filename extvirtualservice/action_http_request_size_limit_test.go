@@ -0,0 +1,28 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_toRequestSizeLimitConfigPatches(t *testing.T) {
+	patches, err := toRequestSizeLimitConfigPatches(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"maxRequestBytes": 512.0,
+		},
+	}))
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+	require.Equal(t, map[string]any{
+		"name": "steadybit.filters.http.request_size_limit",
+		"typed_config": map[string]any{
+			"@type":             "type.googleapis.com/envoy.extensions.filters.http.buffer.v3.Buffer",
+			"max_request_bytes": 512.0,
+		},
+	}, patches[0].Patch.Value.AsMap())
+}
@@ -0,0 +1,111 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+type HttpResponseBandwidthAction struct {
+}
+
+func NewHttpResponseBandwidthAction() action_kit_sdk.Action[EnvoyFilterActionState] {
+	return HttpResponseBandwidthAction{}
+}
+
+var _ action_kit_sdk.Action[EnvoyFilterActionState] = (*HttpResponseBandwidthAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EnvoyFilterActionState] = (*HttpResponseBandwidthAction)(nil)
+
+func (f HttpResponseBandwidthAction) NewEmptyState() EnvoyFilterActionState {
+	return EnvoyFilterActionState{}
+}
+
+func (f HttpResponseBandwidthAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.http.response-bandwidth", VirtualServiceTargetID),
+		Label:       "HTTP Response Bandwidth",
+		Description: "Limits the response bandwidth of the workloads behind the destinations of the targeted virtual services through a temporary EnvoyFilter, emulating slow downloads of large responses.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(targetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: VirtualServiceTargetID,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "name",
+					Query: "istio.virtual-service.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Istio"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the response bandwidth should be limited."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "percentage",
+				Label:        "Percentage",
+				Description:  new("Percentage of requests whose responses will be limited."),
+				Type:         action_kit_api.ActionParameterTypePercentage,
+				DefaultValue: new("100"),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "bandwidth",
+				Label:        "Bandwidth (KiB/s)",
+				Description:  new("Maximum response bandwidth in KiB/s."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("64"),
+				MinValue:     new(1),
+				Required:     new(true),
+				Order:        new(2),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpResponseBandwidthAction) Prepare(ctx context.Context, state *EnvoyFilterActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareEnvoyFilter(ctx, state, request, toResponseBandwidthConfigPatches)
+}
+
+func (f HttpResponseBandwidthAction) Start(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	return startEnvoyFilter(ctx, state)
+}
+
+func (f HttpResponseBandwidthAction) Stop(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopEnvoyFilter(ctx, state)
+}
+
+func toResponseBandwidthConfigPatches(request action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	patch, err := toInboundHTTPFilterPatch("steadybit.filters.http.response_bandwidth", map[string]any{
+		"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+		"response_rate_limit": map[string]any{
+			"fixed_limit": map[string]any{
+				"limit_kbps": extutil.ToInt64(request.Config["bandwidth"]),
+			},
+			"percentage": toFractionalPercent(request.Config["percentage"].(float64)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{patch}, nil
+}
@@ -0,0 +1,43 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+	"testing"
+)
+
+func Test_toResponseBandwidthConfigPatches(t *testing.T) {
+	patches, err := toResponseBandwidthConfigPatches(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{
+		Config: map[string]any{
+			"bandwidth":  128.0,
+			"percentage": 42.5,
+		},
+	}))
+	require.NoError(t, err)
+	require.Len(t, patches, 1)
+
+	patch := patches[0]
+	require.Equal(t, networkingv1alpha3.EnvoyFilter_HTTP_FILTER, patch.ApplyTo)
+	require.Equal(t, networkingv1alpha3.EnvoyFilter_SIDECAR_INBOUND, patch.Match.Context)
+	require.Equal(t, networkingv1alpha3.EnvoyFilter_Patch_INSERT_BEFORE, patch.Patch.Operation)
+	require.Equal(t, map[string]any{
+		"name": "steadybit.filters.http.response_bandwidth",
+		"typed_config": map[string]any{
+			"@type": "type.googleapis.com/envoy.extensions.filters.http.fault.v3.HTTPFault",
+			"response_rate_limit": map[string]any{
+				"fixed_limit": map[string]any{
+					"limit_kbps": 128.0,
+				},
+				"percentage": map[string]any{
+					"numerator":   425000.0,
+					"denominator": "MILLION",
+				},
+			},
+		},
+	}, patch.Patch.Value.AsMap())
+}
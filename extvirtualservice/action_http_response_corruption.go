@@ -0,0 +1,184 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"context"
+	"fmt"
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/action-kit/go/action_kit_sdk"
+	"github.com/steadybit/extension-kit/extbuild"
+	"github.com/steadybit/extension-kit/extutil"
+	networkingv1alpha3 "istio.io/api/networking/v1alpha3"
+)
+
+const (
+	responseCorruptionModeCorrupt  = "corrupt"
+	responseCorruptionModeTruncate = "truncate"
+	responseCorruptionModeStatus   = "status"
+)
+
+// luaResponseCorruptionScripts contain the manipulation done by the Lua filter for the selected share of responses.
+var luaResponseCorruptionScripts = map[string]string{
+	responseCorruptionModeCorrupt: `
+  local body = response_handle:body()
+  if body == nil or body:length() == 0 then
+    return
+  end
+  local corrupted = string.gsub(body:getBytes(0, body:length()), ".", function(c)
+    if math.random() < 0.1 then
+      return string.char(math.random(0, 255))
+    end
+  end)
+  body:setBytes(corrupted)`,
+	responseCorruptionModeTruncate: `
+  local body = response_handle:body()
+  if body == nil or body:length() == 0 then
+    return
+  end
+  body:setBytes(body:getBytes(0, math.floor(body:length() / 2)))`,
+	responseCorruptionModeStatus: `
+  response_handle:headers():replace(":status", "%d")`,
+}
+
+type HttpResponseCorruptionAction struct {
+}
+
+func NewHttpResponseCorruptionAction() action_kit_sdk.Action[EnvoyFilterActionState] {
+	return HttpResponseCorruptionAction{}
+}
+
+var _ action_kit_sdk.Action[EnvoyFilterActionState] = (*HttpResponseCorruptionAction)(nil)
+var _ action_kit_sdk.ActionWithStop[EnvoyFilterActionState] = (*HttpResponseCorruptionAction)(nil)
+
+func (f HttpResponseCorruptionAction) NewEmptyState() EnvoyFilterActionState {
+	return EnvoyFilterActionState{}
+}
+
+func (f HttpResponseCorruptionAction) Describe() action_kit_api.ActionDescription {
+	return action_kit_api.ActionDescription{
+		Id:          fmt.Sprintf("%s.http.response-corruption", VirtualServiceTargetID),
+		Label:       "HTTP Response Corruption",
+		Description: "Corrupts or truncates response bodies, or rewrites the response status after the upstream answered, through a temporary Lua EnvoyFilter on the workloads behind the destinations of the targeted virtual services.",
+		Version:     extbuild.GetSemverVersionStringOrUnknown(),
+		Icon:        new(targetIcon),
+		TargetSelection: new(action_kit_api.TargetSelection{
+			TargetType: VirtualServiceTargetID,
+			SelectionTemplates: new([]action_kit_api.TargetSelectionTemplate{
+				{
+					Label: "name",
+					Query: "istio.virtual-service.name=\"\"",
+				},
+			}),
+		}),
+		Technology:  new("Istio"),
+		Kind:        action_kit_api.Attack,
+		TimeControl: action_kit_api.TimeControlExternal,
+		Parameters: []action_kit_api.ActionParameter{
+			{
+				Name:         "duration",
+				Label:        "Duration",
+				Description:  new("Duration defining for how long the responses should be corrupted."),
+				Type:         action_kit_api.ActionParameterTypeDuration,
+				DefaultValue: new("30s"),
+				Required:     new(true),
+				Order:        new(0),
+			},
+			{
+				Name:         "percentage",
+				Label:        "Percentage",
+				Description:  new("Percentage of responses which will be corrupted."),
+				Type:         action_kit_api.ActionParameterTypePercentage,
+				DefaultValue: new("50"),
+				Required:     new(true),
+				Order:        new(1),
+			},
+			{
+				Name:         "mode",
+				Label:        "Mode",
+				Description:  new("How the responses should be corrupted."),
+				Type:         action_kit_api.ActionParameterTypeString,
+				DefaultValue: new(responseCorruptionModeCorrupt),
+				Options: new([]action_kit_api.ParameterOption{
+					action_kit_api.ExplicitParameterOption{
+						Label: "Corrupt body (replace random bytes)",
+						Value: responseCorruptionModeCorrupt,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Truncate body (drop the second half)",
+						Value: responseCorruptionModeTruncate,
+					},
+					action_kit_api.ExplicitParameterOption{
+						Label: "Rewrite status code",
+						Value: responseCorruptionModeStatus,
+					},
+				}),
+				Required: new(true),
+				Order:    new(2),
+			},
+			{
+				Name:         "statusCode",
+				Label:        "HTTP status code",
+				Description:  new("HTTP status code the response status is rewritten to. Only used for the mode 'Rewrite status code'."),
+				Type:         action_kit_api.ActionParameterTypeInteger,
+				DefaultValue: new("500"),
+				MinValue:     new(100),
+				MaxValue:     new(599),
+				Required:     new(false),
+				Order:        new(3),
+			},
+		},
+		Prepare: action_kit_api.MutatingEndpointReference{},
+		Start:   action_kit_api.MutatingEndpointReference{},
+		Stop:    new(action_kit_api.MutatingEndpointReference{}),
+	}
+}
+
+func (f HttpResponseCorruptionAction) Prepare(ctx context.Context, state *EnvoyFilterActionState, request action_kit_api.PrepareActionRequestBody) (*action_kit_api.PrepareResult, error) {
+	return nil, prepareEnvoyFilter(ctx, state, request, toResponseCorruptionConfigPatches)
+}
+
+func (f HttpResponseCorruptionAction) Start(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StartResult, error) {
+	return startEnvoyFilter(ctx, state)
+}
+
+func (f HttpResponseCorruptionAction) Stop(ctx context.Context, state *EnvoyFilterActionState) (*action_kit_api.StopResult, error) {
+	return nil, stopEnvoyFilter(ctx, state)
+}
+
+func toResponseCorruptionConfigPatches(request action_kit_api.PrepareActionRequestBody) ([]*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch, error) {
+	script, err := toResponseCorruptionScript(request)
+	if err != nil {
+		return nil, err
+	}
+
+	patch, err := toInboundHTTPFilterPatch("steadybit.filters.http.response_corruption", map[string]any{
+		"@type": "type.googleapis.com/envoy.extensions.filters.http.lua.v3.Lua",
+		"default_source_code": map[string]any{
+			"inline_string": script,
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return []*networkingv1alpha3.EnvoyFilter_EnvoyConfigObjectPatch{patch}, nil
+}
+
+func toResponseCorruptionScript(request action_kit_api.PrepareActionRequestBody) (string, error) {
+	mode := extutil.ToString(request.Config["mode"])
+	manipulation, ok := luaResponseCorruptionScripts[mode]
+	if !ok {
+		return "", fmt.Errorf("unknown mode '%s'", mode)
+	}
+	if mode == responseCorruptionModeStatus {
+		manipulation = fmt.Sprintf(manipulation, extutil.ToInt(request.Config["statusCode"]))
+	}
+
+	return fmt.Sprintf(`function envoy_on_response(response_handle)
+  if math.random() * 100 >= %g then
+    return
+  end%s
+end
+`, request.Config["percentage"].(float64), manipulation), nil
+}
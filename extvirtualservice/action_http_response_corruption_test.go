@@ -0,0 +1,73 @@
+// SPDX-License-Identifier: MIT
+// SPDX-FileCopyrightText: 2023 Steadybit GmbH
+
+package extvirtualservice
+
+import (
+	"github.com/steadybit/action-kit/go/action_kit_api/v2"
+	"github.com/steadybit/extension-kit/extutil"
+	"github.com/stretchr/testify/require"
+	"testing"
+)
+
+func Test_toResponseCorruptionScript(t *testing.T) {
+	tests := []struct {
+		name    string
+		config  map[string]any
+		want    string
+		wantErr bool
+	}{
+		{
+			name: "rewrites the status",
+			config: map[string]any{
+				"percentage": 25.0,
+				"mode":       "status",
+				"statusCode": 502.0,
+			},
+			want: `function envoy_on_response(response_handle)
+  if math.random() * 100 >= 25 then
+    return
+  end
+  response_handle:headers():replace(":status", "502")
+end
+`,
+		},
+		{
+			name: "truncates the body",
+			config: map[string]any{
+				"percentage": 100.0,
+				"mode":       "truncate",
+			},
+			want: `function envoy_on_response(response_handle)
+  if math.random() * 100 >= 100 then
+    return
+  end
+  local body = response_handle:body()
+  if body == nil or body:length() == 0 then
+    return
+  end
+  body:setBytes(body:getBytes(0, math.floor(body:length() / 2)))
+end
+`,
+		},
+		{
+			name: "rejects unknown modes",
+			config: map[string]any{
+				"percentage": 100.0,
+				"mode":       "explode",
+			},
+			wantErr: true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := toResponseCorruptionScript(extutil.JsonMangle(action_kit_api.PrepareActionRequestBody{Config: tt.config}))
+			if tt.wantErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
@@ -22,7 +22,9 @@ type ActionState struct {
 	Headers           map[string]*networkingv1.StringMatch
 }
 
-func getAdvancedTargetingParameters(startOrder int) []action_kit_api.ActionParameter {
+// GetAdvancedTargetingParameters returns the parameters restricting a fault injection to requests with certain headers
+// or from certain sources.
+func GetAdvancedTargetingParameters(startOrder int) []action_kit_api.ActionParameter {
 	return []action_kit_api.ActionParameter{
 		{
 			Name:        "headers",
@@ -77,10 +79,27 @@ func prepareVirtualServiceFault(state *ActionState,
 	request action_kit_api.PrepareActionRequestBody,
 	toFault func(req action_kit_api.PrepareActionRequestBody) *networkingv1.HTTPFaultInjection) error {
 
-	headers, err := extutil.ToKeyValue(request.Config, "headers")
+	headers, sourceLabels, err := toFaultMatch(request)
 	if err != nil {
 		return extension_kit.ToError("Failed prepare attack", err)
 	}
+
+	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
+	state.Name = request.Target.Attributes["istio.virtual-service.name"][0]
+	state.FaultyRoutePrefix = fmt.Sprintf("steadybit-injected-fault_%s", request.ExecutionId)
+	state.Fault = toFault(request)
+	state.Headers = headers
+	state.SourceLabels = sourceLabels
+	return nil
+}
+
+// toFaultMatch returns the headers and source labels the fault injection is restricted to, as configured through the
+// advanced targeting parameters.
+func toFaultMatch(request action_kit_api.PrepareActionRequestBody) (map[string]*networkingv1.StringMatch, map[string]string, error) {
+	headers, err := extutil.ToKeyValue(request.Config, "headers")
+	if err != nil {
+		return nil, nil, err
+	}
 	headersMatchType := request.Config["headersMatchType"].(string)
 
 	headersWithMatchType := make(map[string]*networkingv1.StringMatch, len(headers))
@@ -111,16 +130,9 @@ func prepareVirtualServiceFault(state *ActionState,
 
 	sourceLabels, err := extutil.ToKeyValue(request.Config, "sourceLabels")
 	if err != nil {
-		return extension_kit.ToError("Failed prepare attack", err)
+		return nil, nil, err
 	}
-
-	state.Namespace = request.Target.Attributes["k8s.namespace"][0]
-	state.Name = request.Target.Attributes["istio.virtual-service.name"][0]
-	state.FaultyRoutePrefix = fmt.Sprintf("steadybit-injected-fault_%s", request.ExecutionId)
-	state.Fault = toFault(request)
-	state.Headers = headersWithMatchType
-	state.SourceLabels = sourceLabels
-	return nil
+	return headersWithMatchType, sourceLabels, nil
 }
 
 func startVirtualServiceFault(ctx context.Context, state *ActionState) error {
@@ -80,7 +80,7 @@ func Test_attackLifecycle(t *testing.T) {
 		},
 	})
 	state := ActionState{}
-	err = prepareVirtualServiceFault(&state, prepareRequest, toHTTPDelayFault)
+	err = prepareVirtualServiceFault(&state, prepareRequest, ToHTTPDelayFault)
 	require.NoError(t, err)
 
 	// Start call
@@ -226,7 +226,7 @@ func Test_attackLifecycle_with_client_restriction(t *testing.T) {
 		},
 	})
 	state := ActionState{}
-	err = prepareVirtualServiceFault(&state, prepareRequest, toHTTPDelayFault)
+	err = prepareVirtualServiceFault(&state, prepareRequest, ToHTTPDelayFault)
 	require.NoError(t, err)
 
 	// Start call
@@ -396,7 +396,7 @@ func Test_attackLifecycle_with_source_label(t *testing.T) {
 		},
 	})
 	state := ActionState{}
-	err = prepareVirtualServiceFault(&state, prepareRequest, toHTTPDelayFault)
+	err = prepareVirtualServiceFault(&state, prepareRequest, ToHTTPDelayFault)
 	require.NoError(t, err)
 
 	// Start call
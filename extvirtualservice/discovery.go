@@ -12,6 +12,9 @@ import (
 	"github.com/steadybit/extension-istio/extclient"
 	"github.com/steadybit/extension-istio/extconfig"
 	"github.com/steadybit/extension-kit/extbuild"
+	networkingv1 "istio.io/api/networking/v1"
+	apinetworkingv1 "istio.io/client-go/pkg/apis/networking/v1"
+	"slices"
 	"time"
 )
 
@@ -77,6 +80,34 @@ func (d *serviceDiscovery) DescribeAttributes() []discovery_kit_api.AttributeDes
 				Other: "Virtual Services",
 			},
 		},
+		{
+			Attribute: "istio.virtual-service.destination-host",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination host",
+				Other: "Destination hosts",
+			},
+		},
+		{
+			Attribute: "istio.virtual-service.destination-subset",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination subset",
+				Other: "Destination subsets",
+			},
+		},
+		{
+			Attribute: "istio.virtual-service.destination-rule",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Destination Rule",
+				Other: "Destination Rules",
+			},
+		},
+		{
+			Attribute: "istio.virtual-service.missing-subset",
+			Label: discovery_kit_api.PluralLabel{
+				One:   "Undefined destination subset",
+				Other: "Undefined destination subsets",
+			},
+		},
 	}
 }
 
@@ -94,6 +125,8 @@ func getVirtualServiceTargets(client *extclient.IstioClient) []discovery_kit_api
 		attributes["k8s.namespace"] = []string{virtualService.Namespace}
 		attributes["k8s.cluster-name"] = []string{extconfig.Config.ClusterName}
 
+		addDestinationAttributes(client, attributes, virtualService)
+
 		for key, value := range virtualService.Labels {
 			attributes["k8s.virtual-service.label."+key] = []string{value}
 		}
@@ -108,3 +141,30 @@ func getVirtualServiceTargets(client *extclient.IstioClient) []discovery_kit_api
 
 	return discovery_kit_commons.ApplyAttributeExcludes(result, extconfig.Config.DiscoveryAttributesExcludesVirtualSerice)
 }
+
+// addDestinationAttributes links the VirtualService to the hosts and subsets it routes to and to the DestinationRules
+// applying to these hosts. Hosts are fully qualified like istio.destination-rule.host, subsets are reported as
+// <host>/<subset>, as their names are only unique per host. Subsets not defined by any of these DestinationRules are
+// reported as missing.
+func addDestinationAttributes(client *extclient.IstioClient, attributes map[string][]string, virtualService *apinetworkingv1.VirtualService) {
+	for _, destination := range extclient.GetRouteDestinations(virtualService) {
+		host := extclient.ToFullyQualifiedHost(virtualService.Namespace, destination.Host)
+		attributes["istio.virtual-service.destination-host"] = extclient.AppendDistinct(attributes["istio.virtual-service.destination-host"], host)
+
+		subsetDefined := false
+		for _, destinationRule := range client.GetDestinationRulesForHost(host) {
+			attributes["istio.virtual-service.destination-rule"] = extclient.AppendDistinct(attributes["istio.virtual-service.destination-rule"], destinationRule.Namespace+"/"+destinationRule.Name)
+			subsetDefined = subsetDefined || slices.ContainsFunc(destinationRule.Spec.Subsets, func(subset *networkingv1.Subset) bool {
+				return subset.Name == destination.Subset
+			})
+		}
+
+		if destination.Subset != "" {
+			subset := host + "/" + destination.Subset
+			attributes["istio.virtual-service.destination-subset"] = extclient.AppendDistinct(attributes["istio.virtual-service.destination-subset"], subset)
+			if !subsetDefined {
+				attributes["istio.virtual-service.missing-subset"] = extclient.AppendDistinct(attributes["istio.virtual-service.missing-subset"], subset)
+			}
+		}
+	}
+}
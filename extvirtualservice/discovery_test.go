@@ -9,12 +9,15 @@ import (
 	"github.com/steadybit/extension-istio/extconfig"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
+	networkingv1 "istio.io/api/networking/v1"
 	apiv1 "istio.io/client-go/pkg/apis/networking/v1"
 	versionedClient "istio.io/client-go/pkg/clientset/versioned"
 	testclient "istio.io/client-go/pkg/clientset/versioned/fake"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/features"
 	clientfeaturestesting "k8s.io/client-go/features/testing"
+	k8stestclient "k8s.io/client-go/kubernetes/fake"
 	"testing"
 	"time"
 )
@@ -66,12 +69,68 @@ func Test_getDiscoveredVirtualServices(t *testing.T) {
 	}, target.Attributes)
 }
 
-func getTestClient(t testing.TB, stopCh <-chan struct{}) (*extclient.IstioClient, versionedClient.Interface) {
+func getTestClient(t testing.TB, stopCh <-chan struct{}, kubernetesObjects ...runtime.Object) (*extclient.IstioClient, versionedClient.Interface) {
 	// Disable WatchListClient feature gate: the fake client doesn't support
 	// the bookmark events required by the WatchList stream, causing informers
 	// to hang indefinitely.
 	clientfeaturestesting.SetFeatureDuringTest(t, features.WatchListClient, false)
 	clientset := testclient.NewSimpleClientset()
-	client := extclient.NewIstioClient(clientset, stopCh)
+	client := extclient.NewIstioClient(clientset, k8stestclient.NewSimpleClientset(kubernetesObjects...), stopCh)
 	return client, clientset
 }
+
+func Test_getDiscoveredVirtualServicesLinksDestinationRules(t *testing.T) {
+	// Given
+	stopCh := make(chan struct{})
+	defer close(stopCh)
+	client, clientset := getTestClient(t, stopCh)
+	extconfig.Config.ClusterName = "development"
+
+	_, err := clientset.NetworkingV1().DestinationRules("default").Create(context.Background(), &apiv1.DestinationRule{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "default"},
+		Spec: networkingv1.DestinationRule{
+			Host:    "reviews.default.svc.cluster.local",
+			Subsets: []*networkingv1.Subset{{Name: "v1", Labels: map[string]string{"version": "v1"}}},
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = clientset.NetworkingV1().VirtualServices("default").Create(context.Background(), &apiv1.VirtualService{
+		ObjectMeta: v1.ObjectMeta{Name: "reviews", Namespace: "default"},
+		Spec: networkingv1.VirtualService{
+			Hosts: []string{"reviews"},
+			Http: []*networkingv1.HTTPRoute{
+				{
+					Route: []*networkingv1.HTTPRouteDestination{
+						{Destination: &networkingv1.Destination{Host: "reviews", Subset: "v1"}, Weight: 50},
+						{Destination: &networkingv1.Destination{Host: "reviews", Subset: "v2"}, Weight: 50},
+					},
+				},
+			},
+			Tcp: []*networkingv1.TCPRoute{
+				{
+					Route: []*networkingv1.RouteDestination{
+						{Destination: &networkingv1.Destination{Host: "ratings.other.svc"}},
+					},
+				},
+				{
+					Route: []*networkingv1.RouteDestination{
+						{Destination: &networkingv1.Destination{Host: "httpbin.org"}},
+					},
+				},
+			},
+		},
+	}, v1.CreateOptions{})
+	require.NoError(t, err)
+
+	// When
+	assert.Eventually(t, func() bool {
+		return len(getVirtualServiceTargets(client)) == 1 && len(client.GetDestinationRules()) == 1
+	}, time.Minute, 100*time.Millisecond)
+
+	// Then
+	attributes := getVirtualServiceTargets(client)[0].Attributes
+	require.Equal(t, []string{"reviews.default.svc.cluster.local", "ratings.other.svc.cluster.local", "httpbin.org"}, attributes["istio.virtual-service.destination-host"])
+	require.Equal(t, []string{"reviews.default.svc.cluster.local/v1", "reviews.default.svc.cluster.local/v2"}, attributes["istio.virtual-service.destination-subset"])
+	require.Equal(t, []string{"default/reviews"}, attributes["istio.virtual-service.destination-rule"])
+	require.Equal(t, []string{"reviews.default.svc.cluster.local/v2"}, attributes["istio.virtual-service.missing-subset"])
+}
@@ -12,6 +12,10 @@ import (
 	"github.com/steadybit/discovery-kit/go/discovery_kit_sdk"
 	"github.com/steadybit/extension-istio/extclient"
 	"github.com/steadybit/extension-istio/extconfig"
+	"github.com/steadybit/extension-istio/extdestinationrule"
+	"github.com/steadybit/extension-istio/extgateway"
+	"github.com/steadybit/extension-istio/extservice"
+	"github.com/steadybit/extension-istio/extserviceentry"
 	"github.com/steadybit/extension-istio/extvirtualservice"
 	"github.com/steadybit/extension-kit/extbuild"
 	"github.com/steadybit/extension-kit/exthealth"
@@ -38,9 +42,36 @@ func main() {
 	extconfig.ValidateConfiguration()
 
 	discovery_kit_sdk.Register(extvirtualservice.NewVirtualServiceDiscovery())
+	discovery_kit_sdk.Register(extdestinationrule.NewDestinationRuleDiscovery())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewCircuitBreakerAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewOutlierDetectionAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewLoadBalancerAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewClientTLSAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewLocalityFailoverAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewBrokenSubsetAction())
+	action_kit_sdk.RegisterAction(extdestinationrule.NewConnectionTimeoutsAction())
 	action_kit_sdk.RegisterAction(extvirtualservice.NewGrpcAbortAction())
 	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpAbortAction())
 	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpDelayAction())
+	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpResponseBandwidthAction())
+	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpLocalRateLimitAction())
+	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpResponseCorruptionAction())
+	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpRequestSizeLimitAction())
+	action_kit_sdk.RegisterAction(extvirtualservice.NewHttpHeaderControlledFaultsAction())
+	discovery_kit_sdk.Register(extgateway.NewGatewayDiscovery())
+	action_kit_sdk.RegisterAction(extgateway.NewServerDisableAction())
+	action_kit_sdk.RegisterAction(extgateway.NewTLSCredentialAction())
+	discovery_kit_sdk.Register(extserviceentry.NewServiceEntryDiscovery())
+	action_kit_sdk.RegisterAction(extserviceentry.NewHttpAbortAction())
+	action_kit_sdk.RegisterAction(extserviceentry.NewHttpDelayAction())
+	action_kit_sdk.RegisterAction(extserviceentry.NewEndpointPoisoningAction())
+	discovery_kit_sdk.Register(extservice.NewServiceDiscovery())
+	action_kit_sdk.RegisterAction(extservice.NewHttpAbortAction())
+	action_kit_sdk.RegisterAction(extservice.NewHttpDelayAction())
+	action_kit_sdk.RegisterAction(extservice.NewAuthorizationPolicyDenyAction())
+	action_kit_sdk.RegisterAction(extservice.NewPeerAuthenticationStrictAction())
+	action_kit_sdk.RegisterAction(extservice.NewSidecarEgressRestrictionAction())
+	action_kit_sdk.RegisterAction(extservice.NewJwtBreakageAction())
 
 	exthttp.RegisterRevisionedHandler("/", getExtensionList)
 